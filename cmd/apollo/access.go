@@ -0,0 +1,209 @@
+// Copyright (C) 2023 Tycho Softworks.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/gofiber/fiber/v2"
+
+	"apollo/internal"
+	"gitlab.com/tychosoft/service"
+)
+
+type Policy struct {
+	Name     string `json:"name"`
+	Members  []int  `json:"members"`
+	Editable bool   `json:"editable"`
+}
+
+func getPolicies() []Policy {
+	access := apollo.GetAccess()
+	policies := make([]Policy, 0, len(access))
+	for name, policy := range access {
+		policies = append(policies, Policy{name, policy.Members, policy.Editable})
+	}
+
+	sort.Slice(policies, func(i, j int) bool {
+		return policies[i].Name < policies[j].Name
+	})
+	return policies
+}
+
+func viewAccess(ctx *fiber.Ctx) error {
+	lock.RLock()
+	defer lock.RUnlock()
+	err := ctx.Render("access", fiber.Map{
+		"page":  config,
+		"items": getPolicies(),
+	})
+	if err != nil {
+		service.Error(err)
+	}
+	return err
+}
+
+func editAccess(ctx *fiber.Ctx) error {
+	name := ctx.Params("id")
+	policy := apollo.GetAccess()[name]
+	if policy == nil {
+		return ctx.Status(fiber.StatusNotFound).SendString("Policy is invalid")
+	}
+
+	form := "edit-access"
+	if !policy.Editable {
+		form = "show-access"
+	}
+
+	lock.RLock()
+	defer lock.RUnlock()
+	err := ctx.Render(form, fiber.Map{
+		"page":    config,
+		"Id":      name,
+		"Members": joinMembers(policy.Members),
+	})
+	if err != nil {
+		service.Error(err)
+	}
+	return err
+}
+
+func postNewAccess(ctx *fiber.Ctx) error {
+	name := strings.ToLower(ctx.FormValue("policy"))
+	if apollo.ExistsPolicy(name) {
+		return ctx.Status(fiber.StatusBadRequest).SendString("Policy already exists")
+	}
+
+	return updateAccess(ctx, name)
+}
+
+func postAccess(ctx *fiber.Ctx) error {
+	name := ctx.Params("id")
+	if !apollo.ExistsPolicy(name) {
+		return ctx.Status(fiber.StatusNotFound).SendString("Policy is invalid")
+	}
+
+	return updateAccess(ctx, name)
+}
+
+func updateAccess(ctx *fiber.Ctx, name string) error {
+	members, err := apollo.ParseMembers(ctx.FormValue("members"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+
+	err = apollo.UpdatePolicy(name, members)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGHUP)
+	return ctx.Redirect("/access", fiber.StatusSeeOther)
+}
+
+func deleteAccess(ctx *fiber.Ctx) error {
+	name := ctx.Params("id")
+	if name != ctx.FormValue("policy") {
+		return ctx.Status(fiber.StatusBadRequest).SendString("policy does not match id")
+	}
+
+	err := apollo.RemovePolicy(name)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGHUP)
+	return ctx.Redirect("/access", fiber.StatusSeeOther)
+}
+
+func aclLine(ctx *fiber.Ctx) error {
+	id, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		id = 0
+	}
+
+	err = apollo.UpdateACL(id, ctx.FormValue("acl"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGHUP)
+	return ctx.Redirect("/lines/"+ctx.Params("id"), fiber.StatusSeeOther)
+}
+
+// admin json api
+
+func adminAccess(ctx *fiber.Ctx) error {
+	return ctx.JSON(getPolicies())
+}
+
+func adminPutAccess(ctx *fiber.Ctx) error {
+	var policy Policy
+	err := ctx.BodyParser(&policy)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	err = apollo.UpdatePolicy(ctx.Params("id"), policy.Members)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGHUP)
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+func adminDeleteAccess(ctx *fiber.Ctx) error {
+	name := ctx.Params("id")
+	if !apollo.ExistsPolicy(name) {
+		return fiber.NewError(fiber.StatusNotFound, "Policy not found")
+	}
+
+	err := apollo.RemovePolicy(name)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGHUP)
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+func adminPutACL(ctx *fiber.Ctx) error {
+	var line struct {
+		ACL string `json:"acl"`
+	}
+
+	err := ctx.BodyParser(&line)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	id, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "Line not found")
+	}
+
+	err = apollo.UpdateACL(id, line.ACL)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGHUP)
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,79 @@
+// Copyright (C) 2023 Tycho Softworks.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"apollo/internal"
+	"gitlab.com/tychosoft/service"
+)
+
+const logPage = 50
+
+func watchCalls() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for tick := 0; ; tick++ {
+		select {
+		case <-eventsDone:
+			return
+		case <-ticker.C:
+		}
+		apollo.SampleCalls()
+		if tick%10 == 0 {
+			go locateCalls()
+		}
+	}
+}
+
+func viewCallLog(ctx *fiber.Ctx) error {
+	page := ctx.QueryInt("page", 1)
+	if page < 1 {
+		page = 1
+	}
+
+	records, total := apollo.GetRecords((page-1)*logPage, logPage)
+	pages := (total + logPage - 1) / logPage
+	if pages < 1 {
+		pages = 1
+	}
+	prev, next := 0, 0
+	if page > 1 {
+		prev = page - 1
+	}
+	if page < pages {
+		next = page + 1
+	}
+
+	lock.RLock()
+	defer lock.RUnlock()
+	err := ctx.Render("call-log", fiber.Map{
+		"page":    config,
+		"items":   records,
+		"total":   total,
+		"current": page,
+		"pages":   pages,
+		"prev":    prev,
+		"next":    next,
+	})
+	if err != nil {
+		service.Error(err)
+	}
+	return err
+}
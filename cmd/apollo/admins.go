@@ -0,0 +1,152 @@
+// Copyright (C) 2023 Tycho Softworks.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"strings"
+	"syscall"
+
+	"github.com/gofiber/fiber/v2"
+
+	"apollo/internal"
+	"gitlab.com/tychosoft/service"
+)
+
+// admin routes after basic auth require at least the given role
+func requireRole(role string) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		user, _ := ctx.Locals("username").(string)
+		current := apollo.AdminRole(user)
+		if !apollo.HasRole(current, role) {
+			service.Warn("admin ", user, " denied ", ctx.Method(), " ", ctx.Path())
+			return ctx.Status(fiber.StatusForbidden).SendString("Permission denied")
+		}
+
+		ctx.Locals("role", current)
+		return ctx.Next()
+	}
+}
+
+func viewAdmins(ctx *fiber.Ctx) error {
+	lock.RLock()
+	defer lock.RUnlock()
+	err := ctx.Render("admins", fiber.Map{
+		"page":   config,
+		"admins": apollo.GetAdmins(),
+		"roles":  []string{apollo.RoleAdmin, apollo.RoleOperator, apollo.RoleAuditor},
+	})
+	if err != nil {
+		service.Error(err)
+	}
+	return err
+}
+
+func editAdmin(ctx *fiber.Ctx) error {
+	admin := apollo.GetAdmin(ctx.Params("id"))
+	if admin == nil || !admin.Editable {
+		return ctx.Status(fiber.StatusNotFound).SendString("Admin is invalid")
+	}
+
+	lock.RLock()
+	defer lock.RUnlock()
+	err := ctx.Render("edit-admin", fiber.Map{
+		"page":  config,
+		"Admin": admin,
+		"roles": []string{apollo.RoleAdmin, apollo.RoleOperator, apollo.RoleAuditor},
+	})
+	if err != nil {
+		service.Error(err)
+	}
+	return err
+}
+
+func postNewAdmin(ctx *fiber.Ctx) error {
+	username := strings.TrimSpace(ctx.FormValue("username"))
+	passwd := ctx.FormValue("pass")
+	if passwd != ctx.FormValue("verify") {
+		return ctx.Status(fiber.StatusBadRequest).SendString("Password does not match verify.")
+	}
+
+	err := apollo.NewAdmin(username, passwd, ctx.FormValue("role"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+
+	service.Info("admin ", authorName(ctx), " added ", strings.ToLower(username))
+	syscall.Kill(syscall.Getpid(), syscall.SIGHUP)
+	return ctx.Redirect("/admins", fiber.StatusSeeOther)
+}
+
+func postAdmin(ctx *fiber.Ctx) error {
+	username := ctx.Params("id")
+	if username == authorName(ctx) {
+		return ctx.Status(fiber.StatusBadRequest).SendString("Cannot change your own role")
+	}
+
+	err := apollo.UpdateAdmin(username, ctx.FormValue("role"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+
+	service.Info("admin ", authorName(ctx), " set ", username, " to ", ctx.FormValue("role"))
+	syscall.Kill(syscall.Getpid(), syscall.SIGHUP)
+	return ctx.Redirect("/admins", fiber.StatusSeeOther)
+}
+
+func disableAdmin(ctx *fiber.Ctx) error {
+	username := ctx.Params("id")
+	if username == authorName(ctx) {
+		return ctx.Status(fiber.StatusBadRequest).SendString("Cannot disable your own account")
+	}
+
+	err := apollo.DisableAdmin(username, true)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+
+	service.Info("admin ", authorName(ctx), " disabled ", username)
+	syscall.Kill(syscall.Getpid(), syscall.SIGHUP)
+	return ctx.Redirect("/admins", fiber.StatusSeeOther)
+}
+
+func enableAdmin(ctx *fiber.Ctx) error {
+	username := ctx.Params("id")
+	err := apollo.DisableAdmin(username, false)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+
+	service.Info("admin ", authorName(ctx), " enabled ", username)
+	syscall.Kill(syscall.Getpid(), syscall.SIGHUP)
+	return ctx.Redirect("/admins", fiber.StatusSeeOther)
+}
+
+func resetAdmin(ctx *fiber.Ctx) error {
+	username := ctx.Params("id")
+	passwd := ctx.FormValue("pass")
+	if passwd != ctx.FormValue("verify") {
+		return ctx.Status(fiber.StatusBadRequest).SendString("Password does not match verify.")
+	}
+
+	err := apollo.ResetAdmin(username, passwd)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+
+	service.Info("admin ", authorName(ctx), " reset password of ", username)
+	syscall.Kill(syscall.Getpid(), syscall.SIGHUP)
+	return ctx.Redirect("/admins", fiber.StatusSeeOther)
+}
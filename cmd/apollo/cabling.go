@@ -0,0 +1,138 @@
+// Copyright (C) 2023 Tycho Softworks.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/gofiber/fiber/v2"
+
+	"apollo/internal"
+	"gitlab.com/tychosoft/service"
+)
+
+type PanelMap struct {
+	Panel *apollo.Panel
+	Ports []*PortMap
+}
+
+type PortMap struct {
+	Port    int
+	Jack    *apollo.Jack
+	Display string
+}
+
+func viewCabling(ctx *fiber.Ctx) error {
+	lock.RLock()
+	defer lock.RUnlock()
+	err := ctx.Render("cabling", fiber.Map{
+		"page":   config,
+		"panels": apollo.GetPanels(),
+		"jacks":  apollo.GetJacks(),
+	})
+	if err != nil {
+		service.Error(err)
+	}
+	return err
+}
+
+// every port of every panel, for printing
+func viewCableMap(ctx *fiber.Ctx) error {
+	jacks := make(map[string]*apollo.Jack)
+	for _, jack := range apollo.GetJacks() {
+		jacks[jack.Panel+":"+strconv.Itoa(jack.Port)] = jack
+	}
+
+	lines := apollo.GetLines()
+	var panels []*PanelMap
+	for _, panel := range apollo.GetPanels() {
+		item := &PanelMap{Panel: panel}
+		for port := 1; port <= panel.Ports; port++ {
+			entry := &PortMap{Port: port, Jack: jacks[panel.Name+":"+strconv.Itoa(port)]}
+			if entry.Jack != nil && lines[entry.Jack.Line] != nil {
+				entry.Display = lines[entry.Jack.Line].Display
+			}
+			item.Ports = append(item.Ports, entry)
+		}
+		panels = append(panels, item)
+	}
+
+	lock.RLock()
+	defer lock.RUnlock()
+	err := ctx.Render("cable-map", fiber.Map{
+		"page":   config,
+		"panels": panels,
+	})
+	if err != nil {
+		service.Error(err)
+	}
+	return err
+}
+
+func postPanel(ctx *fiber.Ctx) error {
+	ports, err := strconv.Atoi(ctx.FormValue("ports"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).SendString("Invalid number of ports")
+	}
+
+	err = apollo.UpdatePanel(&apollo.Panel{
+		Name:     strings.TrimSpace(ctx.FormValue("panel")),
+		Location: strings.TrimSpace(ctx.FormValue("location")),
+		Ports:    ports,
+	})
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+	return ctx.Redirect("/cabling", fiber.StatusSeeOther)
+}
+
+func deletePanel(ctx *fiber.Ctx) error {
+	err := apollo.RemovePanel(ctx.FormValue("panel"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+	return ctx.Redirect("/cabling", fiber.StatusSeeOther)
+}
+
+func postJack(ctx *fiber.Ctx) error {
+	port, err := strconv.Atoi(ctx.FormValue("port"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).SendString("Invalid panel port")
+	}
+
+	err = apollo.UpdateJack(&apollo.Jack{
+		Id:    strings.TrimSpace(ctx.FormValue("jack")),
+		Panel: ctx.FormValue("panel"),
+		Port:  port,
+		Room:  strings.TrimSpace(ctx.FormValue("room")),
+	}, strings.TrimSpace(ctx.FormValue("zone")))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGHUP)
+	return ctx.Redirect("/cabling", fiber.StatusSeeOther)
+}
+
+func deleteJack(ctx *fiber.Ctx) error {
+	err := apollo.RemoveJack(ctx.FormValue("jack"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+	return ctx.Redirect("/cabling", fiber.StatusSeeOther)
+}
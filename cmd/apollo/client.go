@@ -38,9 +38,41 @@ func clientProfile(ctx *fiber.Ctx) error {
 }
 
 func clientRoster(ctx *fiber.Ctx) error {
-	return ctx.JSON(apollo.GetLines())
+	lines := apollo.GetLines()
+	if zone := ctx.Query("zone"); zone != "" {
+		lines = zoneLines(lines, zone)
+	}
+	return ctx.JSON(lines)
 }
 
 func clientGroups(ctx *fiber.Ctx) error {
 	return ctx.JSON(apollo.GetGroups())
 }
+
+// global directory merged with the caller's own contacts
+func clientContacts(ctx *fiber.Ctx) error {
+	id := ctx.Locals("userID").(int)
+	return ctx.JSON(apollo.LineContacts(id))
+}
+
+func clientCalls(ctx *fiber.Ctx) error {
+	return ctx.JSON(apollo.GetCalls())
+}
+
+func clientPresence(ctx *fiber.Ctx) error {
+	var body struct {
+		Status string `json:"status" form:"status"`
+	}
+
+	id := ctx.Locals("userID").(int)
+	err := ctx.BodyParser(&body)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	err = apollo.SetPresence(id, body.Status)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,135 @@
+// Copyright (C) 2023 Tycho Softworks.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+
+	"apollo/internal"
+	"gitlab.com/tychosoft/service"
+)
+
+func contactForm(ctx *fiber.Ctx, id int) *apollo.Contact {
+	line, _ := strconv.Atoi(ctx.FormValue("line"))
+	return &apollo.Contact{
+		Id:           id,
+		Name:         ctx.FormValue("name"),
+		Number:       ctx.FormValue("number"),
+		Speed:        ctx.FormValue("speed"),
+		Organization: ctx.FormValue("organization"),
+		Line:         line,
+	}
+}
+
+func editContact(ctx *fiber.Ctx) error {
+	id, _ := strconv.Atoi(ctx.Params("id"))
+	contact := apollo.GetContact(id)
+	if contact == nil {
+		return ctx.Status(fiber.StatusNotFound).SendString("Contact is invalid")
+	}
+
+	lock.RLock()
+	defer lock.RUnlock()
+	err := ctx.Render("edit-contact", fiber.Map{
+		"page":    config,
+		"Contact": contact,
+	})
+	if err != nil {
+		service.Error(err)
+	}
+	return err
+}
+
+func postNewContact(ctx *fiber.Ctx) error {
+	_, err := apollo.UpdateContact(contactForm(ctx, 0))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+	return ctx.Redirect("/contacts", fiber.StatusSeeOther)
+}
+
+func postContact(ctx *fiber.Ctx) error {
+	id, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(fiber.StatusNotFound).SendString("Contact is invalid")
+	}
+
+	_, err = apollo.UpdateContact(contactForm(ctx, id))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+	return ctx.Redirect("/contacts", fiber.StatusSeeOther)
+}
+
+func deleteContact(ctx *fiber.Ctx) error {
+	id, _ := strconv.Atoi(ctx.Params("id"))
+	err := apollo.RemoveContact(id)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+	return ctx.Redirect("/contacts", fiber.StatusSeeOther)
+}
+
+// admin json api
+
+func adminContacts(ctx *fiber.Ctx) error {
+	return ctx.JSON(apollo.GetContacts())
+}
+
+func adminPostContact(ctx *fiber.Ctx) error {
+	var contact apollo.Contact
+	err := ctx.BodyParser(&contact)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	contact.Id = 0
+	id, err := apollo.UpdateContact(&contact)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	return ctx.Status(fiber.StatusCreated).JSON(apollo.GetContact(id))
+}
+
+func adminPutContact(ctx *fiber.Ctx) error {
+	var contact apollo.Contact
+	err := ctx.BodyParser(&contact)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	contact.Id, err = strconv.Atoi(ctx.Params("id"))
+	if err != nil || contact.Id < 1 {
+		return fiber.NewError(fiber.StatusNotFound, "Contact not found")
+	}
+
+	_, err = apollo.UpdateContact(&contact)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+func adminDeleteContact(ctx *fiber.Ctx) error {
+	id, _ := strconv.Atoi(ctx.Params("id"))
+	err := apollo.RemoveContact(id)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,101 @@
+// Copyright (C) 2023 Tycho Softworks.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/gofiber/fiber/v2"
+
+	"apollo/internal"
+	"gitlab.com/tychosoft/service"
+)
+
+// coverage is edited from either the lines or groups path
+func coverageKind(ctx *fiber.Ctx) string {
+	if strings.HasPrefix(ctx.Route().Path, "/lines") {
+		return "lines"
+	}
+	return "groups"
+}
+
+// coverage of the line or group in the path, nil if the id is the wrong kind
+func pathCoverage(ctx *fiber.Ctx) (string, *apollo.Coverage) {
+	id := ctx.Params("id")
+	number, _ := strconv.Atoi(id)
+	if (coverageKind(ctx) == "lines") != (number < 100) {
+		return id, nil
+	}
+	return id, apollo.GetCoverage(id)
+}
+
+func renderCoverage(ctx *fiber.Ctx, id string, cover *apollo.Coverage) error {
+	lock.RLock()
+	defer lock.RUnlock()
+	err := ctx.Render("coverage", fiber.Map{
+		"page":     config,
+		"Id":       id,
+		"Kind":     coverageKind(ctx),
+		"Targets":  strings.Join(cover.Targets, ", "),
+		"Delayed":  cover.Delayed,
+		"Sequence": apollo.RingSequence(id, cover),
+	})
+	if err != nil {
+		service.Error(err)
+	}
+	return err
+}
+
+func editCoverage(ctx *fiber.Ctx) error {
+	id, cover := pathCoverage(ctx)
+	if cover == nil {
+		return ctx.Status(fiber.StatusNotFound).SendString("Line or group is invalid")
+	}
+
+	return renderCoverage(ctx, id, cover)
+}
+
+func postCoverage(ctx *fiber.Ctx) error {
+	id, saved := pathCoverage(ctx)
+	if saved == nil {
+		return ctx.Status(fiber.StatusNotFound).SendString("Line or group is invalid")
+	}
+
+	targets, err := apollo.ParseTargets(id, ctx.FormValue("targets"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+
+	delayed, err := apollo.ParseDelay(ctx.FormValue("delayed"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+
+	cover := &apollo.Coverage{Targets: targets, Delayed: delayed}
+	if ctx.FormValue("action") != "save" {
+		return renderCoverage(ctx, id, cover)
+	}
+
+	err = apollo.UpdateCoverage(id, cover)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGHUP)
+	return ctx.Redirect("/"+coverageKind(ctx)+"/"+id, fiber.StatusSeeOther)
+}
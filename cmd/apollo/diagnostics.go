@@ -0,0 +1,130 @@
+// Copyright (C) 2023 Tycho Softworks.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+
+	"apollo/internal"
+	"gitlab.com/tychosoft/service"
+)
+
+type Levels struct {
+	Apollo   int  `json:"apollo"`
+	Saved    int  `json:"apollo_saved"`
+	Coventry *int `json:"coventry"`
+	Bordeaux *int `json:"bordeaux"`
+}
+
+// current levels, nil when a daemon is not reachable
+func getLevels() *Levels {
+	lock.RLock()
+	levels := &Levels{Apollo: apollo.Verbose(), Saved: config.Verbose}
+	lock.RUnlock()
+
+	sys := apollo.GetSystem()
+	if sys != nil {
+		level := int(sys.Level)
+		levels.Coventry = &level
+	}
+
+	level, ok := apollo.MediaLevel()
+	if ok {
+		levels.Bordeaux = &level
+	}
+	return levels
+}
+
+func setLevel(daemon string, level int) error {
+	if level < 0 || level > apollo.MaxLevel {
+		return fmt.Errorf("logging level must be 0 to %d", apollo.MaxLevel)
+	}
+
+	service.Info("set ", daemon, " level ", level)
+	switch daemon {
+	case "apollo":
+		apollo.SetVerbose(level)
+		lock.Lock()
+		defer lock.Unlock()
+		err := os.WriteFile(workingDir+"/verbose", []byte(strconv.Itoa(level)+"\n"), 0640)
+		if err != nil {
+			return fmt.Errorf("level set but not saved: %w", err)
+		}
+		config.Verbose = level
+		return nil
+	case "coventry":
+		return apollo.LevelCoventry(level)
+	case "bordeaux":
+		return apollo.LevelBordeaux(level)
+	default:
+		return fmt.Errorf("unknown service %s", daemon)
+	}
+}
+
+func viewDiagnostics(ctx *fiber.Ctx) error {
+	levels := getLevels()
+	lock.RLock()
+	defer lock.RUnlock()
+	err := ctx.Render("diagnostics", fiber.Map{
+		"page":   config,
+		"levels": levels,
+		"max":    apollo.MaxLevel,
+	})
+	if err != nil {
+		service.Error(err)
+	}
+	return err
+}
+
+func postLevel(ctx *fiber.Ctx) error {
+	level, err := strconv.Atoi(ctx.FormValue("level"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).SendString("Invalid logging level")
+	}
+
+	err = setLevel(ctx.Params("daemon"), level)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+	return ctx.Redirect("/diagnostics", fiber.StatusSeeOther)
+}
+
+// admin json api
+
+func adminLevels(ctx *fiber.Ctx) error {
+	return ctx.JSON(getLevels())
+}
+
+func adminPutLevel(ctx *fiber.Ctx) error {
+	var body struct {
+		Level int `json:"level"`
+	}
+
+	err := ctx.BodyParser(&body)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	err = setLevel(ctx.Params("daemon"), body.Level)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
@@ -18,56 +18,35 @@ package main
 import (
 	"fmt"
 	"os"
-
-	"gopkg.in/ini.v1"
+	"sync"
 
 	"apollo/internal"
 	"gitlab.com/tychosoft/service"
 )
 
-type User struct {
-	Username string
-	Password string
-}
-
 var (
-	dynCoventry *ini.File = nil
-	adminUser   *User
-	setupFlag   bool = true
+	setupFlag bool = true
+	dynOnce   sync.Once
 )
 
 func dynInit(port uint16, tls bool) {
-	var opt = ini.LoadOptions{Loose: true, Insensitive: true}
-	var err error
-	var web = fmt.Sprintf("%d", port)
 	var iniCoventry = workingDir + "/dynamic.conf"
 
-	dynCoventry, err = ini.LoadSources(opt, iniCoventry)
-	if err != nil {
-		dynCoventry = &ini.File{}
-	}
-	section := dynCoventry.Section("server")
-	apollo.SetConfig(section, "webserver", web)
+	server := apollo.GetServer()
+	apollo.UpdateCoventry("server", "webserver", fmt.Sprintf("%d", port))
 	if tls {
-		apollo.SetConfig(section, "urlschema", "https")
+		apollo.UpdateCoventry("server", "urlschema", "https")
 	} else {
-		apollo.SetConfig(section, "urlschema", "http")
+		apollo.UpdateCoventry("server", "urlschema", "http")
 	}
 
-	if !section.HasKey("webadmin") {
-		section.NewKey("webadmin", "admin")
+	if !server.HasKey("webadmin") {
+		apollo.UpdateCoventry("server", "webadmin", "admin")
 	}
 
-	setupFlag = section.HasKey("webpass")
-	adminUser = &User{
-		Username: apollo.GetConfig(section, "webadmin", "admin"),
-		Password: apollo.GetConfig(section, "webpass", "XXX"),
-	}
-	err = dynCoventry.SaveTo(iniCoventry)
+	setupFlag = server.HasKey("webpass")
+	err := apollo.SaveCoventry()
 	os.Chmod(iniCoventry, 0600)
-	if err == nil {
-		err = apollo.ReloadCoventry()
-	}
 	if err != nil {
 		service.Error(err)
 	}
@@ -17,6 +17,7 @@ package main
 
 import (
 	"strconv"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 
@@ -67,9 +68,89 @@ func editLine(ctx *fiber.Ctx) error {
 	lock.RLock()
 	defer lock.RUnlock()
 	err = ctx.Render(form, fiber.Map{
-		"page": config,
-		"Id":   id,
-		"Line": line,
+		"page":     config,
+		"Id":       id,
+		"Line":     line,
+		"Policies": getPolicies(),
+		"Tickets":  apollo.OpenTickets(id),
+		"Jacks":    apollo.GetJacks(),
+		"Rooms":    roomNames(),
+	})
+	if err != nil {
+		service.Error(err)
+	}
+	return err
+}
+
+func addGroup(ctx *fiber.Ctx) error {
+	id, group := apollo.NewGroup()
+	if group == nil {
+		return ctx.Status(fiber.StatusBadRequest).SendString("No groups available")
+	}
+
+	lock.RLock()
+	defer lock.RUnlock()
+	err := ctx.Render("add-group", fiber.Map{
+		"page":  config,
+		"Id":    id,
+		"Group": group,
+	})
+	if err != nil {
+		service.Error(err)
+	}
+	return err
+}
+
+func editGroup(ctx *fiber.Ctx) error {
+	id := ctx.Params("id")
+	if id == "new" {
+		return addGroup(ctx)
+	}
+
+	group := apollo.GetGroup(id)
+	if group == nil || group.Type != "group" {
+		return ctx.Status(fiber.StatusNotFound).SendString("Group is invalid")
+	}
+
+	form := "edit-group"
+	if !group.Editable {
+		form = "show-group"
+	}
+
+	lock.RLock()
+	defer lock.RUnlock()
+	err := ctx.Render(form, fiber.Map{
+		"page":    config,
+		"Id":      id,
+		"Group":   group,
+		"Members": joinMembers(group.Members),
+	})
+	if err != nil {
+		service.Error(err)
+	}
+	return err
+}
+
+func joinMembers(members []int) string {
+	list := make([]string, 0, len(members))
+	for _, member := range members {
+		list = append(list, strconv.Itoa(member))
+	}
+	return strings.Join(list, ", ")
+}
+
+// any logged in admin may change their own password
+func editPasswd(ctx *fiber.Ctx) error {
+	admin := apollo.GetAdmin(authorName(ctx))
+	if admin == nil {
+		return ctx.Status(fiber.StatusNotFound).SendString("Admin is invalid")
+	}
+
+	lock.RLock()
+	defer lock.RUnlock()
+	err := ctx.Render("passwd", fiber.Map{
+		"page":  config,
+		"Admin": admin,
 	})
 	if err != nil {
 		service.Error(err)
@@ -81,7 +162,9 @@ func editSettings(ctx *fiber.Ctx) error {
 	lock.RLock()
 	defer lock.RUnlock()
 	err := ctx.Render("settings", fiber.Map{
-		"page": config,
+		"page":   config,
+		"Trunk":  apollo.GetTrunk(),
+		"Status": apollo.GetTrunkStatus(),
 	})
 	if err != nil {
 		service.Error(err)
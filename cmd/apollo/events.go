@@ -0,0 +1,133 @@
+// Copyright (C) 2023 Tycho Softworks.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"apollo/internal"
+)
+
+var (
+	eventLock    sync.Mutex
+	eventClients = make(map[chan []byte]bool)
+	eventsDone   = make(chan struct{})
+	registryLast = make(map[int]*apollo.Registry)
+)
+
+func subscribeEvents() chan []byte {
+	events := make(chan []byte, 128)
+	eventLock.Lock()
+	defer eventLock.Unlock()
+	eventClients[events] = true
+
+	// new clients start with the full registry
+	for _, entry := range registryLast {
+		data, err := json.Marshal(entry)
+		if err == nil {
+			select {
+			case events <- data:
+			default:
+			}
+		}
+	}
+	return events
+}
+
+func unsubscribeEvents(events chan []byte) {
+	eventLock.Lock()
+	defer eventLock.Unlock()
+	delete(eventClients, events)
+}
+
+func stopEvents() {
+	close(eventsDone)
+}
+
+// slow clients drop events rather than stall the watcher
+func publishEvent(entry *apollo.Registry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	for events := range eventClients {
+		select {
+		case events <- data:
+		default:
+		}
+	}
+}
+
+func watchRegistry() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-eventsDone:
+			return
+		case <-ticker.C:
+		}
+
+		current := apollo.GetRegistry()
+		eventLock.Lock()
+		for id, entry := range current {
+			last := registryLast[id]
+			if last == nil || *last != *entry {
+				publishEvent(entry)
+			}
+		}
+		for id := range registryLast {
+			if current[id] == nil {
+				publishEvent(&apollo.Registry{Id: id, Agent: "offline", Presence: "down"})
+			}
+		}
+		registryLast = current
+		eventLock.Unlock()
+	}
+}
+
+func streamEvents(ctx *fiber.Ctx) error {
+	ctx.Set("Content-Type", "text/event-stream")
+	ctx.Set("Cache-Control", "no-cache")
+	ctx.Set("Connection", "keep-alive")
+	events := subscribeEvents()
+	ctx.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer unsubscribeEvents(events)
+		keepalive := time.NewTicker(30 * time.Second)
+		defer keepalive.Stop()
+		for {
+			select {
+			case <-eventsDone:
+				return
+			case data := <-events:
+				fmt.Fprintf(w, "event: registry\ndata: %s\n\n", data)
+			case <-keepalive.C:
+				fmt.Fprint(w, ": keepalive\n\n")
+			}
+			if w.Flush() != nil {
+				return
+			}
+		}
+	})
+	return nil
+}
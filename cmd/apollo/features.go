@@ -0,0 +1,99 @@
+// Copyright (C) 2023 Tycho Softworks.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"net/url"
+	"syscall"
+
+	"github.com/gofiber/fiber/v2"
+
+	"apollo/internal"
+	"gitlab.com/tychosoft/service"
+)
+
+func featureCode(ctx *fiber.Ctx) string {
+	code, err := url.PathUnescape(ctx.Params("code"))
+	if err != nil {
+		return ""
+	}
+	return code
+}
+
+func viewFeatures(ctx *fiber.Ctx) error {
+	lock.RLock()
+	defer lock.RUnlock()
+	err := ctx.Render("features", fiber.Map{
+		"page":  config,
+		"items": apollo.GetFeatureCodes(),
+	})
+	if err != nil {
+		service.Error(err)
+	}
+	return err
+}
+
+func postFeature(ctx *fiber.Ctx) error {
+	err := apollo.UpdateFeature(ctx.FormValue("code"), ctx.FormValue("action"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGHUP)
+	return ctx.Redirect("/features", fiber.StatusSeeOther)
+}
+
+func deleteFeature(ctx *fiber.Ctx) error {
+	err := apollo.RemoveFeature(featureCode(ctx))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGHUP)
+	return ctx.Redirect("/features", fiber.StatusSeeOther)
+}
+
+// admin json api
+
+func adminFeatures(ctx *fiber.Ctx) error {
+	return ctx.JSON(apollo.GetFeatureCodes())
+}
+
+func adminPutFeature(ctx *fiber.Ctx) error {
+	var feature apollo.Feature
+	err := ctx.BodyParser(&feature)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	err = apollo.UpdateFeature(featureCode(ctx), feature.Action)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGHUP)
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+func adminDeleteFeature(ctx *fiber.Ctx) error {
+	err := apollo.RemoveFeature(featureCode(ctx))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGHUP)
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,82 @@
+// Copyright (C) 2023 Tycho Softworks.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"syscall"
+
+	"github.com/gofiber/fiber/v2"
+
+	"apollo/internal"
+)
+
+func deleteGroup(ctx *fiber.Ctx) error {
+	id := ctx.Params("id")
+	if id != ctx.FormValue("group") {
+		return ctx.Status(fiber.StatusBadRequest).SendString("group does not match id")
+	}
+
+	err := apollo.RemoveGroup(id)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGHUP)
+	return ctx.Redirect("/groups", fiber.StatusSeeOther)
+}
+
+func postNewGroup(ctx *fiber.Ctx) error {
+	id := ctx.FormValue("group")
+	if apollo.ExistsGroup(id) {
+		return ctx.Status(fiber.StatusBadRequest).SendString("Group already exists")
+	}
+
+	return updateGroup(ctx, id)
+}
+
+func postGroup(ctx *fiber.Ctx) error {
+	id := ctx.Params("id")
+	group := apollo.GetGroup(id)
+	if group == nil || group.Type != "group" {
+		return ctx.Status(fiber.StatusNotFound).SendString("Group is invalid")
+	}
+
+	if !group.Editable {
+		return ctx.Status(fiber.StatusBadRequest).SendString("Custom groups not changeable")
+	}
+
+	return updateGroup(ctx, id)
+}
+
+func updateGroup(ctx *fiber.Ctx, id string) error {
+	members, err := apollo.ParseMembers(ctx.FormValue("members"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+
+	save := &apollo.Group{
+		Display: ctx.FormValue("display"),
+		Members: members,
+	}
+
+	err = apollo.UpdateGroup(id, save)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGHUP)
+	return ctx.Redirect("/groups", fiber.StatusSeeOther)
+}
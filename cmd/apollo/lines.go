@@ -68,6 +68,18 @@ func passwdLine(ctx *fiber.Ctx) error {
 		return ctx.Status(fiber.StatusBadRequest).SendString("Password not set.")
 	}
 
+	setDigests(save, ext, passwd)
+	err = apollo.UpdateLine(id, save)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGHUP)
+	return ctx.Redirect("/lines", fiber.StatusSeeOther)
+}
+
+// replace line secrets with digests of a new password
+func setDigests(save *apollo.Line, ext, passwd string) {
 	save.MD5 = ""
 	save.SHA256 = ""
 	save.Secret = ""
@@ -79,10 +91,24 @@ func passwdLine(ctx *fiber.Ctx) error {
 	if apollo.HasSHA256() {
 		save.SHA256 = apollo.ComputeSHA256(ext, passwd)
 	}
+}
 
-	apollo.UpdateLine(id, save)
-	syscall.Kill(syscall.Getpid(), syscall.SIGHUP)
-	return ctx.Redirect("/lines", fiber.StatusSeeOther)
+func presenceLine(ctx *fiber.Ctx) error {
+	ext := ctx.Params("id")
+	id, err := strconv.Atoi(ext)
+	if err != nil {
+		id = 0
+	}
+
+	if !apollo.ExistsLine(id) {
+		return ctx.Status(fiber.StatusNotFound).SendString("Line is invalid")
+	}
+
+	err = apollo.SetPresence(id, ctx.FormValue("presence"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+	return ctx.Redirect("/lines/"+ext, fiber.StatusSeeOther)
 }
 
 func postNewLine(ctx *fiber.Ctx) error {
@@ -122,7 +148,11 @@ func postNewLine(ctx *fiber.Ctx) error {
 		}
 	}
 
-	apollo.UpdateLine(id, save)
+	err = apollo.UpdateLine(id, save)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+
 	syscall.Kill(syscall.Getpid(), syscall.SIGHUP)
 	return ctx.Redirect("/lines", fiber.StatusSeeOther)
 }
@@ -162,7 +192,14 @@ func postLine(ctx *fiber.Ctx) error {
 	}
 
 	if ctx.FormValue("cabling") != line.Cabling {
+		room, err := apollo.CheckCabling(id, ctx.FormValue("cabling"))
+		if err != nil {
+			return ctx.Status(fiber.StatusBadRequest).SendString(err.Error())
+		}
 		save.Cabling = ctx.FormValue("cabling")
+		if room != "" {
+			save.Room = room
+		}
 	}
 
 	if ctx.FormValue("location") != line.Location {
@@ -179,7 +216,11 @@ func postLine(ctx *fiber.Ctx) error {
 		return ctx.Status(fiber.StatusBadRequest).SendString(err.Error())
 	}
 
-	apollo.UpdateLine(id, save)
+	err = apollo.UpdateLine(id, save)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+
 	syscall.Kill(syscall.Getpid(), syscall.SIGHUP)
 	return ctx.Redirect("/lines", fiber.StatusSeeOther)
 }
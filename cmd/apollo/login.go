@@ -0,0 +1,210 @@
+// Copyright (C) 2023 Tycho Softworks.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/basicauth"
+
+	"apollo/internal"
+	"gitlab.com/tychosoft/service"
+)
+
+// failed logins from one address before it is locked out
+const (
+	loginAttempts = 5
+	loginLockout  = 15 * time.Minute
+)
+
+type loginFailures struct {
+	count  int
+	last   time.Time
+	locked time.Time
+}
+
+var (
+	failures   = make(map[string]*loginFailures)
+	failedLock sync.Mutex
+)
+
+func loginLocked(ip string) bool {
+	failedLock.Lock()
+	defer failedLock.Unlock()
+	failed := failures[ip]
+	return failed != nil && time.Since(failed.locked) < loginLockout
+}
+
+func loginFailed(ip, who string) {
+	now := time.Now()
+	failedLock.Lock()
+	defer failedLock.Unlock()
+	for addr, failed := range failures {
+		if now.Sub(failed.last) > loginLockout {
+			delete(failures, addr)
+		}
+	}
+
+	failed := failures[ip]
+	if failed == nil {
+		failed = &loginFailures{}
+		failures[strings.Clone(ip)] = failed
+	}
+
+	failed.count++
+	failed.last = now
+	service.Warn("login failed for ", who, " from ", ip)
+	if failed.count >= loginAttempts {
+		failed.count = 0
+		failed.locked = now
+		service.Warn("login locked out for ", ip, " after ", loginAttempts, " failures")
+	}
+}
+
+func loginPassed(ip string) {
+	failedLock.Lock()
+	defer failedLock.Unlock()
+	delete(failures, ip)
+}
+
+func sessionAdmin(ctx *fiber.Ctx) string {
+	sess, err := sessions.Get(ctx)
+	if err != nil {
+		return ""
+	}
+
+	user, _ := sess.Get("admin").(string)
+	return user
+}
+
+// admin pages require a logged in session of an enabled admin
+func adminSession(ctx *fiber.Ctx) error {
+	user := sessionAdmin(ctx)
+	if len(user) < 1 || apollo.AdminRole(user) == "" {
+		if ctx.Method() == fiber.MethodGet {
+			return ctx.Redirect("/login", fiber.StatusSeeOther)
+		}
+		return ctx.Status(fiber.StatusUnauthorized).SendString("Please login")
+	}
+
+	ctx.Locals("username", user)
+	return ctx.Next()
+}
+
+// admin json api also takes basic auth for scripts, without a challenge so
+// browsers never cache the credentials.
+func adminBasic() fiber.Handler {
+	basic := basicauth.New(basicauth.Config{
+		Authorizer: func(user, pass string) bool {
+			_, ok := apollo.VerifyAdmin(user, pass)
+			return ok
+		},
+		Unauthorized: func(ctx *fiber.Ctx) error {
+			loginFailed(ctx.IP(), "api")
+			return ctx.SendStatus(fiber.StatusUnauthorized)
+		},
+	})
+
+	return func(ctx *fiber.Ctx) error {
+		if len(ctx.Get(fiber.HeaderAuthorization)) < 1 {
+			return adminSession(ctx)
+		}
+
+		if loginLocked(ctx.IP()) {
+			return ctx.SendStatus(fiber.StatusTooManyRequests)
+		}
+		return basic(ctx)
+	}
+}
+
+func viewLogin(ctx *fiber.Ctx) error {
+	if !setupFlag {
+		return ctx.Redirect("/setup", fiber.StatusSeeOther)
+	}
+
+	lock.RLock()
+	defer lock.RUnlock()
+	err := ctx.Render("login", fiber.Map{
+		"page": config,
+	})
+	if err != nil {
+		service.Error(err)
+	}
+	return err
+}
+
+func postLogin(ctx *fiber.Ctx) error {
+	ip := ctx.IP()
+	if loginLocked(ip) {
+		service.Warn("login refused for locked out ", ip)
+		return ctx.Status(fiber.StatusTooManyRequests).SendString("Too many failed logins, try again later")
+	}
+
+	user := strings.TrimSpace(ctx.FormValue("username"))
+	_, ok := apollo.VerifyAdmin(user, ctx.FormValue("pass"))
+	if !ok {
+		loginFailed(ip, user)
+		return ctx.Status(fiber.StatusUnauthorized).SendString("Invalid username or password")
+	}
+
+	loginPassed(ip)
+	sess, err := sessions.Get(ctx)
+	if err == nil {
+		err = sess.Regenerate()
+	}
+	if err == nil {
+		sess.Set("admin", strings.Clone(user))
+		err = sess.Save()
+	}
+	if err != nil {
+		service.Error(err)
+		return ctx.Status(fiber.StatusInternalServerError).SendString("Cannot start session")
+	}
+
+	service.Info("admin ", user, " logged in from ", ip)
+	return ctx.Redirect("/lines", fiber.StatusSeeOther)
+}
+
+// end one identity, keeping any other login in the same session
+func endSession(ctx *fiber.Ctx, key string) (any, error) {
+	sess, err := sessions.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	value := sess.Get(key)
+	sess.Delete(key)
+	err = sess.Regenerate()
+	if err == nil {
+		err = sess.Save()
+	}
+	return value, err
+}
+
+func postLogout(ctx *fiber.Ctx) error {
+	value, err := endSession(ctx, "admin")
+	if err != nil {
+		service.Error(err)
+	}
+
+	if user, _ := value.(string); len(user) > 0 {
+		service.Info("admin ", user, " logged out")
+	}
+	return ctx.Redirect("/login", fiber.StatusSeeOther)
+}
@@ -16,18 +16,19 @@
 package main
 
 import (
-	"crypto/sha256"
-	"encoding/hex"
 	"fmt"
 	"os"
 	"os/signal"
 	"runtime"
+	"strconv"
+	"strings"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/alexflint/go-arg"
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/basicauth"
+	"github.com/gofiber/fiber/v2/middleware/csrf"
 	"github.com/gofiber/template/html/v2"
 	"gopkg.in/ini.v1"
 
@@ -61,6 +62,13 @@ type Config struct {
 	Country  string `ini:"country" arg:"-"`
 	IpToken  string `ini:"token" arg:"-"`
 
+	// call accounting retention
+	Retain  int `ini:"retain" arg:"-"`
+	Records int `ini:"records" arg:"-"`
+
+	// login session idle timeout in minutes
+	Idle int `ini:"idle" arg:"-"`
+
 	// other config info sent to page
 	Realm    string `ini:"-" arg:"-"`
 	Digests  string `ini:"-" arg:"-"`
@@ -79,6 +87,7 @@ type Weather struct {
 var (
 	// binding configs
 	workingDir = "/var/lib/coventry"
+	mediaData  = "/var/lib/bordeaux"
 	appDataDir = "/usr/share/apollo"
 	etcPrefix  = "/etc"
 	logPrefix  = "/var/log"
@@ -114,6 +123,18 @@ func init() {
 	}
 }
 
+func verboseArg() bool {
+	for _, arg := range os.Args[1:] {
+		if arg == "--" {
+			break
+		}
+		if strings.HasPrefix(arg, "--verbose") {
+			return true
+		}
+	}
+	return false
+}
+
 func load() {
 	// default config
 	new_config := Config{
@@ -135,6 +156,9 @@ func load() {
 		Region:   "unknown",
 		Postal:   "unknown",
 		Country:  "us",
+		Retain:   90,
+		Records:  50000,
+		Idle:     30,
 	}
 
 	new_weather := Weather{
@@ -149,6 +173,7 @@ func load() {
 		configs.MapTo(&new_config)
 		configs.Section("server").MapTo(&new_config)
 		configs.Section("certs").MapTo(&new_config)
+		configs.Section("accounting").MapTo(&new_config)
 		configs.Section("weather").MapTo(&new_weather)
 	} else {
 		service.Error(err)
@@ -159,10 +184,26 @@ func load() {
 		new_config.Host = ""
 	}
 
+	// sessions must always expire
+	if new_config.Idle < 1 {
+		service.Warn("invalid idle time ", new_config.Idle, ", using 30 minutes")
+		new_config.Idle = 30
+	}
+
 	err = apollo.Config(etcPrefix, workingDir)
 	if err != nil {
 		service.Error(err)
 	}
+	apollo.Media(mediaData)
+
+	// diagnostics level saved by apollo unless given on the command line
+	saved, err := os.ReadFile(workingDir + "/verbose")
+	if err == nil && !verboseArg() {
+		level, err := strconv.Atoi(strings.TrimSpace(string(saved)))
+		if err == nil && level >= 0 && level <= apollo.MaxLevel {
+			new_config.Verbose = level
+		}
+	}
 
 	// set page values from full config...
 	server := apollo.GetServer()
@@ -183,9 +224,9 @@ func load() {
 	common := apollo.GetCommon()
 	new_config.Pass = apollo.GetConfig(common, "password", "")
 
-	if dynCoventry == nil {
+	dynOnce.Do(func() {
 		dynInit(new_config.Port, new_config.Secure)
-	}
+	})
 
 	lock.Lock()
 	defer lock.Unlock()
@@ -202,12 +243,33 @@ func main() {
 	}
 
 	load()
+	apollo.SetVerbose(config.Verbose)
 	service.Logger(config.Verbose, logPrefix+"/apollo.log")
+	err = apollo.Accounting(workingDir+"/calls.json", config.Retain, config.Records)
+	if err != nil {
+		service.Error(err)
+	}
+	err = apollo.Geolocation(workingDir + "/geo.json")
+	if err != nil {
+		service.Error(err)
+	}
+	err = apollo.Tickets(workingDir + "/tickets.json")
+	if err != nil {
+		service.Error(err)
+	}
+	err = apollo.Cabling(workingDir + "/cabling.json")
+	if err != nil {
+		service.Error(err)
+	}
+	err = apollo.Contacts(workingDir + "/contacts.json")
+	if err != nil {
+		service.Error(err)
+	}
 
 	// setup app and routes
 	address := fmt.Sprintf("%s:%v", config.Host, config.Port)
 	aging := 600
-	service.Debug(3, "prefix=", workingDir, ", bind=", address)
+	apollo.Debug(3, "prefix=", workingDir, ", bind=", address)
 	service.Info("realm ", apollo.Realm, ", algo ", apollo.Algorithm)
 	views := appDataDir + "/views_" + config.Views
 	if flag, _ := apollo.IsDir(views); !flag {
@@ -223,21 +285,17 @@ func main() {
 		ServerHeader:          "Apollo",
 		AppName:               "Apollo v1",
 		DisableStartupMessage: true,
+		PassLocalsToViews:     true,
 		Views:                 engine,
 	})
 
-	admin := basicauth.New(basicauth.Config{
-		Authorizer: func(user, pass string) bool {
-			if user != adminUser.Username {
-				return false
-			}
-
-			digest := sha256.New()
-			digest.Write([]byte(pass + ":" + user))
-			return hex.EncodeToString(digest.Sum(nil)) == adminUser.Password
-		},
-		Realm: apollo.Realm,
-	})
+	sessions.Expiration = time.Duration(config.Idle) * time.Minute
+	sessions.CookieSecure = config.Secure
+	auth := adminSession
+	api := adminBasic()
+	admin := requireRole(apollo.RoleAdmin)
+	operator := requireRole(apollo.RoleOperator)
+	auditor := requireRole(apollo.RoleAuditor)
 
 	user := func(ctx *fiber.Ctx) error {
 		header := ctx.Get("Authorization")
@@ -254,31 +312,151 @@ func main() {
 	}
 
 	app.Static("/assets", appDataDir+"/assets", fiber.Static{MaxAge: aging})
+	app.Use(csrf.New(csrf.Config{
+		KeyLookup:      "form:csrf",
+		CookieName:     "apollo_csrf",
+		CookieSameSite: "Lax",
+		CookieSecure:   config.Secure,
+		CookieHTTPOnly: true,
+		Session:        sessions,
+		ContextKey:     "csrf",
+		Next: func(ctx *fiber.Ctx) bool {
+			// token and basic auth api clients are not cookie based
+			return len(ctx.Get(fiber.HeaderAuthorization)) > 0
+		},
+	}))
+
+	app.Get("/login", viewLogin)
+	app.Post("/login", postLogin)
+	app.Post("/logout", postLogout)
 	app.Post("/setup", postSetup)
-	app.Post("/lines", admin, postNewLine)
-	app.Post("/lines/:id", admin, postLine)
-	app.Post("/lines/:id/delete", admin, deleteLine)
-	app.Post("/lines/:id/passwd", admin, passwdLine)
-	app.Post("/settings/theme", admin, themeSetup)
-	app.Post("/settings/internet", admin, internetSetup)
-	app.Post("/settings/location", admin, locationSetup)
-	app.Delete("/lines/:id", admin, deleteLine)
+	app.Post("/lines", auth, operator, postNewLine)
+	app.Post("/lines/:id", auth, operator, postLine)
+	app.Post("/lines/:id/delete", auth, operator, deleteLine)
+	app.Post("/lines/:id/passwd", auth, operator, passwdLine)
+	app.Post("/lines/:id/coverage", auth, operator, postCoverage)
+	app.Post("/lines/:id/acl", auth, admin, aclLine)
+	app.Post("/lines/:id/room", auth, operator, roomLine)
+	app.Post("/lines/:id/presence", auth, operator, presenceLine)
+	app.Post("/settings/theme", auth, admin, themeSetup)
+	app.Post("/settings/passwd", auth, auditor, passwdSetup)
+	app.Post("/settings/internet", auth, admin, internetSetup)
+	app.Post("/settings/location", auth, admin, locationSetup)
+	app.Post("/settings/realm", auth, admin, realmSetup)
+	app.Post("/settings/trunk", auth, admin, trunkSetup)
+	app.Post("/groups", auth, operator, postNewGroup)
+	app.Post("/groups/:id", auth, operator, postGroup)
+	app.Post("/groups/:id/delete", auth, operator, deleteGroup)
+	app.Post("/groups/:id/coverage", auth, operator, postCoverage)
+	app.Post("/access", auth, admin, postNewAccess)
+	app.Post("/access/:id", auth, admin, postAccess)
+	app.Post("/access/:id/delete", auth, admin, deleteAccess)
+	app.Post("/features", auth, admin, postFeature)
+	app.Post("/features/:code/delete", auth, admin, deleteFeature)
+	app.Post("/messages", auth, operator, postMessage)
+	app.Post("/diagnostics/:daemon", auth, admin, postLevel)
+	app.Post("/media/reload", auth, admin, reloadMedia)
+	app.Delete("/lines/:id", auth, operator, deleteLine)
+	app.Delete("/groups/:id", auth, operator, deleteGroup)
+	app.Delete("/access/:id", auth, admin, deleteAccess)
 
 	// client access api
 	app.Get("/client/v0/ping", user, clientPing)
 	app.Get("/client/v0/profile", user, clientProfile)
 	app.Get("/client/v0/roster", user, clientRoster)
+	app.Get("/client/v0/contacts", user, clientContacts)
 	app.Get("/client/v0/groups", user, clientGroups)
+	app.Get("/client/v0/calls", user, clientCalls)
+	app.Post("/client/v0/message", user, clientMessage)
+	app.Post("/client/v0/presence", user, clientPresence)
+	app.Get("/client/v0/events", user, streamEvents)
+
+	// admin json api
+	app.Get("/admin/v0/access", api, auditor, adminAccess)
+	app.Put("/admin/v0/access/:id", api, admin, adminPutAccess)
+	app.Delete("/admin/v0/access/:id", api, admin, adminDeleteAccess)
+	app.Put("/admin/v0/lines/:id/acl", api, admin, adminPutACL)
+	app.Put("/admin/v0/lines/:id/room", api, operator, adminPutLineRoom)
+	app.Get("/admin/v0/rooms", api, auditor, adminRooms)
+	app.Put("/admin/v0/rooms/:id", api, operator, adminPutRoom)
+	app.Delete("/admin/v0/rooms/:id", api, operator, adminDeleteRoom)
+	app.Get("/admin/v0/contacts", api, auditor, adminContacts)
+	app.Post("/admin/v0/contacts", api, operator, adminPostContact)
+	app.Put("/admin/v0/contacts/:id", api, operator, adminPutContact)
+	app.Delete("/admin/v0/contacts/:id", api, operator, adminDeleteContact)
+	app.Get("/admin/v0/zones", api, auditor, adminZones)
+	app.Put("/admin/v0/zones/:id", api, operator, adminPutZone)
+	app.Delete("/admin/v0/zones/:id", api, operator, adminDeleteZone)
+	app.Get("/admin/v0/features", api, auditor, adminFeatures)
+	app.Put("/admin/v0/features/:code", api, admin, adminPutFeature)
+	app.Delete("/admin/v0/features/:code", api, admin, adminDeleteFeature)
+	app.Get("/admin/v0/levels", api, auditor, adminLevels)
+	app.Put("/admin/v0/levels/:daemon", api, admin, adminPutLevel)
+	app.Get("/admin/v0/media", api, auditor, adminMedia)
+	app.Post("/admin/v0/media/reload", api, admin, adminReloadMedia)
+	app.Get("/admin/v0/remote", api, auditor, adminRemote)
 
 	// main views
-	app.Get("/ping", admin, viewPing)
-	app.Get("/main", admin, viewMain)
-	app.Get("/lines", admin, viewLines)
-	app.Get("/lines/:id", admin, editLine)
-	app.Get("/groups", admin, viewGroups)
-	app.Get("/contacts", admin, viewContacts)
-	app.Get("/settings", admin, editSettings)
+	app.Get("/ping", auth, auditor, viewPing)
+	app.Get("/main", auth, auditor, viewMain)
+	app.Get("/events", auth, auditor, streamEvents)
+	app.Get("/lines", auth, auditor, viewLines)
+	app.Get("/lines/:id", auth, auditor, editLine)
+	app.Get("/lines/:id/coverage", auth, auditor, editCoverage)
+	app.Get("/calls", auth, auditor, viewCalls)
+	app.Get("/calls/log", auth, auditor, viewCallLog)
+	app.Get("/reports/usage.csv", api, auditor, usageCSV)
+	app.Get("/reports/usage.json", api, auditor, usageJSON)
+	app.Get("/remote", auth, auditor, viewRemote)
+	app.Get("/tickets", auth, auditor, viewTickets)
+	app.Post("/tickets", auth, operator, postNewTicket)
+	app.Get("/tickets/:id", auth, auditor, viewTicket)
+	app.Post("/tickets/:id", auth, operator, postTicket)
+	app.Post("/tickets/:id/notes", auth, operator, postTicketNote)
+	app.Get("/cabling", auth, auditor, viewCabling)
+	app.Get("/cabling/map", auth, auditor, viewCableMap)
+	app.Post("/cabling/panels", auth, operator, postPanel)
+	app.Post("/cabling/panels/delete", auth, operator, deletePanel)
+	app.Post("/cabling/jacks", auth, operator, postJack)
+	app.Post("/cabling/jacks/delete", auth, operator, deleteJack)
+	app.Get("/rooms", auth, auditor, viewRooms)
+	app.Post("/rooms", auth, operator, postRoom)
+	app.Get("/rooms/:id", auth, auditor, viewRoom)
+	app.Post("/rooms/:id/delete", auth, operator, deleteRoom)
+	app.Post("/zones", auth, operator, postZone)
+	app.Post("/zones/:id/delete", auth, operator, deleteZone)
+	app.Get("/media", auth, auditor, viewMedia)
+	app.Get("/groups", auth, auditor, viewGroups)
+	app.Get("/groups/:id", auth, auditor, editGroup)
+	app.Get("/groups/:id/coverage", auth, auditor, editCoverage)
+	app.Get("/access", auth, auditor, viewAccess)
+	app.Get("/access/:id", auth, auditor, editAccess)
+	app.Get("/features", auth, auditor, viewFeatures)
+	app.Get("/messages", auth, auditor, viewMessages)
+	app.Get("/contacts", auth, auditor, viewContacts)
+	app.Post("/contacts", auth, operator, postNewContact)
+	app.Get("/contacts/:id", auth, auditor, editContact)
+	app.Post("/contacts/:id", auth, operator, postContact)
+	app.Post("/contacts/:id/delete", auth, operator, deleteContact)
+	app.Get("/settings", auth, admin, editSettings)
+	app.Get("/settings/passwd", auth, auditor, editPasswd)
+	app.Get("/diagnostics", auth, auditor, viewDiagnostics)
+	app.Get("/admins", auth, admin, viewAdmins)
+	app.Post("/admins", auth, admin, postNewAdmin)
+	app.Get("/admins/:id", auth, admin, editAdmin)
+	app.Post("/admins/:id", auth, admin, postAdmin)
+	app.Post("/admins/:id/disable", auth, admin, disableAdmin)
+	app.Post("/admins/:id/enable", auth, admin, enableAdmin)
+	app.Post("/admins/:id/reset", auth, admin, resetAdmin)
 	app.Get("/setup", viewSetup)
+	app.Get("/user", viewUser)
+	app.Post("/user/login", postUserLogin)
+	app.Post("/user/logout", postUserLogout)
+	app.Post("/user/profile", lineUser, postUserProfile)
+	app.Post("/user/passwd", lineUser, postUserPasswd)
+	app.Post("/user/contacts", lineUser, postUserContact)
+	app.Post("/user/contacts/:id/delete", lineUser, deleteUserContact)
+	app.Post("/user/requests", lineUser, postUserRequest)
 	app.Get("/", func(ctx *fiber.Ctx) error {
 		if setupFlag {
 			return ctx.Redirect("/lines", fiber.StatusTemporaryRedirect)
@@ -293,6 +471,7 @@ func main() {
 		service.Live("start service on ", address)
 		defer app.Shutdown()
 		defer service.Stop("stop service")
+		defer stopEvents()
 		for {
 			switch <-signals {
 			case os.Interrupt: // sigint/ctrl-c
@@ -311,6 +490,8 @@ func main() {
 	}()
 
 	// start service(s)...
+	go watchRegistry()
+	go watchCalls()
 	if config.Secure {
 		if err := app.ListenTLS(address, config.Crtfile, config.Keyfile); err != nil {
 			service.Fail(99, err)
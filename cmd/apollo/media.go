@@ -0,0 +1,76 @@
+// Copyright (C) 2023 Tycho Softworks.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"sort"
+
+	"github.com/gofiber/fiber/v2"
+
+	"apollo/internal"
+	"gitlab.com/tychosoft/service"
+)
+
+func getSessions() []*apollo.Session {
+	sessions := apollo.GetSessions()
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].Created.Before(sessions[j].Created)
+	})
+	return sessions
+}
+
+func viewMedia(ctx *fiber.Ctx) error {
+	media := apollo.GetMedia()
+	sessions := getSessions()
+	lock.RLock()
+	defer lock.RUnlock()
+	err := ctx.Render("media", fiber.Map{
+		"page":   config,
+		"system": media,
+		"items":  sessions,
+	})
+	if err != nil {
+		service.Error(err)
+	}
+	return err
+}
+
+func reloadMedia(ctx *fiber.Ctx) error {
+	err := apollo.ReloadBordeaux()
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+
+	service.Info("reload bordeaux")
+	return ctx.Redirect("/media", fiber.StatusSeeOther)
+}
+
+// admin json api
+
+func adminMedia(ctx *fiber.Ctx) error {
+	return ctx.JSON(fiber.Map{
+		"system":   apollo.GetMedia(),
+		"sessions": getSessions(),
+	})
+}
+
+func adminReloadMedia(ctx *fiber.Ctx) error {
+	err := apollo.ReloadBordeaux()
+	if err != nil {
+		return fiber.NewError(fiber.StatusServiceUnavailable, err.Error())
+	}
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,120 @@
+// Copyright (C) 2023 Tycho Softworks.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+
+	"apollo/internal"
+	"gitlab.com/tychosoft/service"
+)
+
+type Message struct {
+	To      string `json:"to" form:"to"`
+	Subject string `json:"subject" form:"subject"`
+	Text    string `json:"text" form:"text"`
+}
+
+// send to a line, a group, or every line for "all"
+func sendMessage(msg *Message) error {
+	var targets []string
+	id, _ := strconv.Atoi(msg.To)
+	switch {
+	case msg.To == "all":
+		for ext := range apollo.GetLines() {
+			targets = append(targets, strconv.Itoa(ext))
+		}
+		sort.Strings(targets)
+	case apollo.ExistsLine(id) && id >= 10 && id <= 89:
+		targets = append(targets, msg.To)
+	case apollo.ExistsGroup(msg.To):
+		targets = append(targets, msg.To)
+	default:
+		return fmt.Errorf("invalid message destination %s", msg.To)
+	}
+
+	for _, to := range targets {
+		err := apollo.SendMessage(to, msg.Subject, msg.Text)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func viewMessages(ctx *fiber.Ctx) error {
+	lock.RLock()
+	defer lock.RUnlock()
+	err := ctx.Render("messages", fiber.Map{
+		"page":    config,
+		"subject": apollo.MessageSubj,
+		"text":    apollo.MessageText,
+	})
+	if err != nil {
+		service.Error(err)
+	}
+	return err
+}
+
+func postMessage(ctx *fiber.Ctx) error {
+	msg := &Message{
+		To:      ctx.FormValue("to"),
+		Subject: ctx.FormValue("subject"),
+		Text:    ctx.FormValue("text"),
+	}
+
+	err := sendMessage(msg)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+
+	service.Info("message sent to ", msg.To)
+	return ctx.Redirect("/messages", fiber.StatusSeeOther)
+}
+
+func clientMessage(ctx *fiber.Ctx) error {
+	var msg Message
+	id := ctx.Locals("userID").(int)
+	err := ctx.BodyParser(&msg)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	if msg.To == "all" {
+		return fiber.NewError(fiber.StatusForbidden, "Broadcast not permitted")
+	}
+
+	// pbx messages have no sender, so it leads the subject
+	if len(msg.Subject) > 0 {
+		prefix := strconv.Itoa(id) + ": "
+		if limit := apollo.MessageSubj - len(prefix); len(msg.Subject) > limit {
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("message subject exceeds %d characters", limit))
+		}
+		msg.Subject = prefix + msg.Subject
+	} else {
+		msg.Subject = strconv.Itoa(id)
+	}
+
+	err = sendMessage(&msg)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
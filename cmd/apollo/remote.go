@@ -0,0 +1,160 @@
+// Copyright (C) 2023 Tycho Softworks.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"sort"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/gofiber/fiber/v2"
+
+	"apollo/internal"
+	"gitlab.com/tychosoft/service"
+)
+
+type Remote struct {
+	Kind     string           `json:"kind"`
+	Id       string           `json:"id"`
+	Host     string           `json:"host"`
+	Location *apollo.Location `json:"location"`
+}
+
+type Country struct {
+	Country string `json:"country"`
+	Calls   int    `json:"calls"`
+	Lines   int    `json:"lines"`
+}
+
+var locating atomic.Bool
+
+func geoToken() string {
+	lock.RLock()
+	defer lock.RUnlock()
+	return config.IpToken
+}
+
+// remote parties of active calls and registered lines
+func getRemotes() []*Remote {
+	var remotes []*Remote
+	var hosts []string
+	for _, call := range apollo.GetCalls() {
+		host := apollo.RemoteHost(call.Remote)
+		if host != "" {
+			remotes = append(remotes, &Remote{Kind: "call", Id: call.Caller + " to " + call.Dialed, Host: host})
+			hosts = append(hosts, host)
+		}
+	}
+
+	lines := apollo.GetLines()
+	ids := make([]int, 0, len(lines))
+	for id := range lines {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		host := apollo.RemoteHost(lines[id].Host)
+		if host != "" {
+			remotes = append(remotes, &Remote{Kind: "line", Id: strconv.Itoa(id), Host: host})
+			hosts = append(hosts, host)
+		}
+	}
+
+	// only cached locations, new addresses are looked up in the background
+	located := apollo.Locate("", hosts)
+	go locateHosts(hosts)
+	for _, remote := range remotes {
+		remote.Location = located[remote.Host]
+	}
+	return remotes
+}
+
+func countRemotes(remotes []*Remote) []*Country {
+	counts := make(map[string]*Country)
+	for _, remote := range remotes {
+		name := "unknown"
+		if remote.Location != nil && remote.Location.Country != "" {
+			name = remote.Location.Country
+		}
+
+		country := counts[name]
+		if country == nil {
+			country = &Country{Country: name}
+			counts[name] = country
+		}
+
+		if remote.Kind == "call" {
+			country.Calls++
+		} else {
+			country.Lines++
+		}
+	}
+
+	countries := make([]*Country, 0, len(counts))
+	for _, country := range counts {
+		countries = append(countries, country)
+	}
+	sort.Slice(countries, func(i, j int) bool {
+		return countries[i].Country < countries[j].Country
+	})
+	return countries
+}
+
+// look up addresses in the background, one lookup pass at a time
+func locateHosts(hosts []string) {
+	if !locating.CompareAndSwap(false, true) {
+		return
+	}
+
+	defer locating.Store(false)
+	apollo.Locate(geoToken(), hosts)
+}
+
+// locate new call remotes in the background for call records
+func locateCalls() {
+	var hosts []string
+	for _, call := range apollo.GetCalls() {
+		host := apollo.RemoteHost(call.Remote)
+		if host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+	locateHosts(hosts)
+}
+
+func viewRemote(ctx *fiber.Ctx) error {
+	remotes := getRemotes()
+	countries := countRemotes(remotes)
+	lock.RLock()
+	defer lock.RUnlock()
+	err := ctx.Render("remote", fiber.Map{
+		"page":      config,
+		"items":     remotes,
+		"countries": countries,
+	})
+	if err != nil {
+		service.Error(err)
+	}
+	return err
+}
+
+func adminRemote(ctx *fiber.Ctx) error {
+	remotes := getRemotes()
+	return ctx.JSON(fiber.Map{
+		"countries": countRemotes(remotes),
+		"remotes":   remotes,
+	})
+}
@@ -0,0 +1,129 @@
+// Copyright (C) 2023 Tycho Softworks.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"apollo/internal"
+)
+
+func parseDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.ParseInLocation("2006-01-02", value, time.Local)
+}
+
+func usageFilter(ctx *fiber.Ctx) (*apollo.UsageFilter, error) {
+	filter := &apollo.UsageFilter{}
+	from, err := parseDate(ctx.Query("from"))
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "invalid from date")
+	}
+
+	to, err := parseDate(ctx.Query("to"))
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "invalid to date")
+	}
+
+	// to date is inclusive
+	filter.From = from
+	if !to.IsZero() {
+		filter.To = to.AddDate(0, 0, 1)
+	}
+
+	if line := ctx.Query("line"); line != "" {
+		filter.Line, err = strconv.Atoi(line)
+		if err != nil || filter.Line < 10 || filter.Line > 89 {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "invalid line")
+		}
+	}
+
+	filter.Direction = ctx.Query("direction")
+	switch filter.Direction {
+	case "", "inbound", "outgoing", "local":
+	default:
+		return nil, fiber.NewError(fiber.StatusBadRequest, "invalid direction")
+	}
+
+	switch ctx.Query("period", "daily") {
+	case "daily":
+	case "monthly":
+		filter.Monthly = true
+	default:
+		return nil, fiber.NewError(fiber.StatusBadRequest, "invalid period")
+	}
+	return filter, nil
+}
+
+func usageCSV(ctx *fiber.Ctx) error {
+	filter, err := usageFilter(ctx)
+	if err != nil {
+		return err
+	}
+
+	usage := apollo.GetUsage(filter)
+	ctx.Set("Content-Type", "text/csv")
+	ctx.Attachment("usage.csv")
+	ctx.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		out := csv.NewWriter(w)
+		out.Write([]string{"period", "line", "direction", "calls", "seconds", "minutes"})
+		for _, entry := range usage {
+			out.Write([]string{
+				entry.Period,
+				strconv.Itoa(entry.Line),
+				entry.Direction,
+				strconv.Itoa(entry.Calls),
+				strconv.FormatInt(entry.Seconds, 10),
+				strconv.FormatInt(entry.Minutes, 10),
+			})
+		}
+		out.Flush()
+	})
+	return nil
+}
+
+func usageJSON(ctx *fiber.Ctx) error {
+	filter, err := usageFilter(ctx)
+	if err != nil {
+		return err
+	}
+
+	usage := apollo.GetUsage(filter)
+	ctx.Set("Content-Type", "application/json")
+	ctx.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		w.WriteString("[")
+		for pos, entry := range usage {
+			if pos > 0 {
+				w.WriteString(",")
+			}
+			data, err := json.Marshal(entry)
+			if err != nil {
+				return
+			}
+			w.Write(data)
+		}
+		w.WriteString("]\n")
+	})
+	return nil
+}
@@ -0,0 +1,253 @@
+// Copyright (C) 2023 Tycho Softworks.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/gofiber/fiber/v2"
+
+	"apollo/internal"
+	"gitlab.com/tychosoft/service"
+)
+
+func viewRooms(ctx *fiber.Ctx) error {
+	lock.RLock()
+	defer lock.RUnlock()
+	err := ctx.Render("rooms", fiber.Map{
+		"page":  config,
+		"rooms": apollo.GetRoomList(),
+		"zones": apollo.GetZoneList(),
+	})
+	if err != nil {
+		service.Error(err)
+	}
+	return err
+}
+
+// lines of a room with live presence
+func viewRoom(ctx *fiber.Ctx) error {
+	type Item struct {
+		Id   int
+		Line *apollo.Line
+	}
+
+	name := ctx.Params("id")
+	var room *apollo.Room
+	for _, entry := range apollo.GetRoomList() {
+		if entry.Name == name {
+			room = entry
+		}
+	}
+
+	if room == nil {
+		return ctx.Status(fiber.StatusNotFound).SendString("Room is invalid")
+	}
+
+	lines := apollo.GetLines()
+	items := make([]Item, 0, len(room.Lines))
+	for _, id := range room.Lines {
+		if lines[id] != nil {
+			items = append(items, Item{id, lines[id]})
+		}
+	}
+
+	lock.RLock()
+	defer lock.RUnlock()
+	err := ctx.Render("room", fiber.Map{
+		"page":  config,
+		"Room":  room,
+		"items": items,
+	})
+	if err != nil {
+		service.Error(err)
+	}
+	return err
+}
+
+func postRoom(ctx *fiber.Ctx) error {
+	err := apollo.UpdateRoom(strings.TrimSpace(ctx.FormValue("room")), ctx.FormValue("zone"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGHUP)
+	return ctx.Redirect("/rooms", fiber.StatusSeeOther)
+}
+
+func deleteRoom(ctx *fiber.Ctx) error {
+	name := ctx.Params("id")
+	if name != ctx.FormValue("room") {
+		return ctx.Status(fiber.StatusBadRequest).SendString("room does not match id")
+	}
+
+	err := apollo.RemoveRoom(name)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGHUP)
+	return ctx.Redirect("/rooms", fiber.StatusSeeOther)
+}
+
+func postZone(ctx *fiber.Ctx) error {
+	err := apollo.UpdateZone(strings.TrimSpace(ctx.FormValue("zone")), strings.TrimSpace(ctx.FormValue("description")))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGHUP)
+	return ctx.Redirect("/rooms", fiber.StatusSeeOther)
+}
+
+func deleteZone(ctx *fiber.Ctx) error {
+	name := ctx.Params("id")
+	if name != ctx.FormValue("zone") {
+		return ctx.Status(fiber.StatusBadRequest).SendString("zone does not match id")
+	}
+
+	err := apollo.RemoveZone(name)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGHUP)
+	return ctx.Redirect("/rooms", fiber.StatusSeeOther)
+}
+
+func roomLine(ctx *fiber.Ctx) error {
+	id, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		id = 0
+	}
+
+	err = apollo.UpdateLineRoom(id, ctx.FormValue("room"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGHUP)
+	return ctx.Redirect("/lines/"+ctx.Params("id"), fiber.StatusSeeOther)
+}
+
+// lines of rooms in a zone
+func zoneLines(lines map[int]*apollo.Line, zone string) map[int]*apollo.Line {
+	zones := apollo.GetRoomZones()
+	for id, line := range lines {
+		if zones[line.Room] != zone {
+			delete(lines, id)
+		}
+	}
+	return lines
+}
+
+func roomNames() []string {
+	var names []string
+	for room := range apollo.GetRoomZones() {
+		names = append(names, room)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// admin json api
+
+func adminRooms(ctx *fiber.Ctx) error {
+	return ctx.JSON(apollo.GetRoomList())
+}
+
+func adminPutRoom(ctx *fiber.Ctx) error {
+	var room apollo.Room
+	err := ctx.BodyParser(&room)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	err = apollo.UpdateRoom(ctx.Params("id"), room.Zone)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGHUP)
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+func adminDeleteRoom(ctx *fiber.Ctx) error {
+	err := apollo.RemoveRoom(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGHUP)
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+func adminZones(ctx *fiber.Ctx) error {
+	return ctx.JSON(apollo.GetZoneList())
+}
+
+func adminPutZone(ctx *fiber.Ctx) error {
+	var zone apollo.Zone
+	err := ctx.BodyParser(&zone)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	err = apollo.UpdateZone(ctx.Params("id"), zone.Description)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGHUP)
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+func adminDeleteZone(ctx *fiber.Ctx) error {
+	err := apollo.RemoveZone(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGHUP)
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+func adminPutLineRoom(ctx *fiber.Ctx) error {
+	var line struct {
+		Room string `json:"room"`
+	}
+
+	err := ctx.BodyParser(&line)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	id, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "Line not found")
+	}
+
+	err = apollo.UpdateLineRoom(id, line.Room)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGHUP)
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
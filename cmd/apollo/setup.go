@@ -16,8 +16,6 @@
 package main
 
 import (
-	"crypto/sha256"
-	"encoding/hex"
 	"net"
 	"strings"
 	"syscall"
@@ -56,30 +54,56 @@ func postSetup(ctx *fiber.Ctx) error {
 	// Process form and goto main...
 	lock.Lock()
 	defer lock.Unlock()
-
-	section := dynCoventry.Section("server")
-	digest := sha256.New()
-	digest.Write([]byte(passwd + ":" + admin))
-	passwd = hex.EncodeToString(digest.Sum(nil))
-	section.NewKey("webpass", passwd)
-	apollo.SetConfig(section, "webadmin", admin)
-
-	iniCoventry := workingDir + "/dynamic.conf"
-	err := dynCoventry.SaveTo(iniCoventry)
+	err := apollo.SetupAdmin(admin, passwd)
 	if err != nil {
 		service.Error(err)
 		return ctx.Status(fiber.StatusBadRequest).SendString("Cannot save setup")
 	}
 
-	adminUser = &User{
-		Username: admin,
-		Password: passwd,
-	}
-
+	syscall.Kill(syscall.Getpid(), syscall.SIGHUP)
 	setupFlag = true
 	return ctx.Redirect("/lines", fiber.StatusSeeOther)
 }
 
+func passwdSetup(ctx *fiber.Ctx) error {
+	user := authorName(ctx)
+	rename := strings.TrimSpace(ctx.FormValue("admin"))
+	if rename == "" {
+		rename = user
+	}
+
+	passwd := ctx.FormValue("pass")
+	if passwd == "" {
+		return ctx.Status(fiber.StatusBadRequest).SendString("Please enter a password.")
+	}
+
+	if passwd != ctx.FormValue("verify") {
+		return ctx.Status(fiber.StatusBadRequest).SendString("Password does not match verify.")
+	}
+
+	err := apollo.ChangeAdmin(user, ctx.FormValue("current"), rename, passwd)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+
+	// keep the session logged in under the new username
+	if rename != user {
+		sess, err := sessions.Get(ctx)
+		if err == nil {
+			sess.Set("admin", strings.Clone(rename))
+			err = sess.Save()
+		}
+		if err != nil {
+			service.Error(err)
+		}
+		service.Info("admin ", user, " renamed to ", rename)
+	}
+
+	service.Info("admin ", rename, " changed password")
+	syscall.Kill(syscall.Getpid(), syscall.SIGHUP)
+	return ctx.Redirect("/settings/passwd", fiber.StatusSeeOther)
+}
+
 func locationSetup(ctx *fiber.Ctx) error {
 	lock.Lock()
 	defer lock.Unlock()
@@ -89,7 +113,7 @@ func locationSetup(ctx *fiber.Ctx) error {
 	apollo.UpdateCoventry("server", "region", ctx.FormValue("region"))
 	apollo.UpdateCoventry("server", "postal", ctx.FormValue("postal"))
 	apollo.SaveCoventry()
-	service.Debug(3, "set where ", ctx.FormValue("where"))
+	apollo.Debug(3, "set where ", ctx.FormValue("where"))
 
 	syscall.Kill(syscall.Getpid(), syscall.SIGHUP)
 	return ctx.Redirect("/lines", fiber.StatusSeeOther)
@@ -126,7 +150,7 @@ func internetSetup(ctx *fiber.Ctx) error {
 	defer lock.Unlock()
 	apollo.UpdateCoventry("server", "token", token)
 	apollo.SaveCoventry()
-	service.Debug(3, "set token ", token)
+	apollo.Debug(3, "set token ", token)
 
 	client := ipinfo.NewClient(nil, nil, token)
 	info, err := client.GetIPInfo(net.ParseIP(pubip))
@@ -157,6 +181,68 @@ func internetSetup(ctx *fiber.Ctx) error {
 	return ctx.Redirect("/lines", fiber.StatusSeeOther)
 }
 
+func realmSetup(ctx *fiber.Ctx) error {
+	realm := strings.TrimSpace(ctx.FormValue("realm"))
+	verify := strings.TrimSpace(ctx.FormValue("verify"))
+	digests := ctx.FormValue("digests")
+
+	if realm != verify {
+		return ctx.Status(fiber.StatusBadRequest).SendString("Realm does not match verify.")
+	}
+
+	if realm == "" {
+		realm = apollo.Realm
+	}
+
+	lines, err := apollo.UpdateRealm(realm, digests)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+
+	service.Info("realm ", apollo.Realm, ", algo ", apollo.Algorithm)
+	err = apollo.ReloadCoventry()
+	if err != nil {
+		service.Error(err)
+	}
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGHUP)
+	lock.RLock()
+	defer lock.RUnlock()
+	err = ctx.Render("realm", fiber.Map{
+		"page":    config,
+		"Realm":   apollo.Realm,
+		"Digests": apollo.Algorithm,
+		"Lines":   lines,
+	})
+	if err != nil {
+		service.Error(err)
+	}
+	return err
+}
+
+func trunkSetup(ctx *fiber.Ctx) error {
+	trunk := apollo.GetTrunk()
+	trunk.Host = strings.TrimSpace(ctx.FormValue("host"))
+	trunk.Username = strings.TrimSpace(ctx.FormValue("username"))
+	trunk.Caller = strings.TrimSpace(ctx.FormValue("caller"))
+	trunk.Prefix = strings.TrimSpace(ctx.FormValue("prefix"))
+
+	// blank secret keeps the existing one
+	secret := ctx.FormValue("secret")
+	if len(secret) > 0 {
+		trunk.Secret = secret
+	}
+
+	err := apollo.UpdateTrunk(trunk)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+
+	service.Info("trunk host ", trunk.Host)
+	syscall.Kill(syscall.Getpid(), syscall.SIGHUP)
+	return ctx.Redirect("/settings", fiber.StatusSeeOther)
+}
+
 func themeSetup(ctx *fiber.Ctx) error {
 	server := apollo.GetServer()
 	theme := apollo.GetConfig(server, "theme", "dark")
@@ -166,7 +252,7 @@ func themeSetup(ctx *fiber.Ctx) error {
 		theme = "light"
 	}
 
-	service.Debug(3, "set theme ", theme)
+	apollo.Debug(3, "set theme ", theme)
 	apollo.UpdateCoventry("server", "theme", theme)
 	apollo.SaveCoventry()
 	syscall.Kill(syscall.Getpid(), syscall.SIGHUP)
@@ -0,0 +1,99 @@
+// Copyright (C) 2023 Tycho Softworks.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+
+	"apollo/internal"
+	"gitlab.com/tychosoft/service"
+)
+
+// authenticated admin name of the request
+func authorName(ctx *fiber.Ctx) string {
+	name, ok := ctx.Locals("username").(string)
+	if !ok {
+		return "admin"
+	}
+	return name
+}
+
+func viewTickets(ctx *fiber.Ctx) error {
+	state := ctx.Query("state")
+	lock.RLock()
+	defer lock.RUnlock()
+	err := ctx.Render("tickets", fiber.Map{
+		"page":  config,
+		"state": state,
+		"items": apollo.GetTickets(state, 0),
+	})
+	if err != nil {
+		service.Error(err)
+	}
+	return err
+}
+
+func viewTicket(ctx *fiber.Ctx) error {
+	id, _ := strconv.Atoi(ctx.Params("id"))
+	ticket := apollo.GetTicket(id)
+	if ticket == nil {
+		return ctx.Status(fiber.StatusNotFound).SendString("Ticket is invalid")
+	}
+
+	lock.RLock()
+	defer lock.RUnlock()
+	err := ctx.Render("ticket", fiber.Map{
+		"page":   config,
+		"Ticket": ticket,
+	})
+	if err != nil {
+		service.Error(err)
+	}
+	return err
+}
+
+func postNewTicket(ctx *fiber.Ctx) error {
+	line, _ := strconv.Atoi(ctx.FormValue("line"))
+	ticket, err := apollo.NewTicket(ctx.FormValue("title"), ctx.FormValue("text"), authorName(ctx), line)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+
+	service.Info("ticket ", ticket.Id, " opened by ", authorName(ctx))
+	return ctx.Redirect("/tickets/"+strconv.Itoa(ticket.Id), fiber.StatusSeeOther)
+}
+
+func postTicket(ctx *fiber.Ctx) error {
+	id, _ := strconv.Atoi(ctx.Params("id"))
+	err := apollo.UpdateTicket(id, ctx.FormValue("state"), ctx.FormValue("assigned"), authorName(ctx))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+
+	return ctx.Redirect("/tickets/"+strconv.Itoa(id), fiber.StatusSeeOther)
+}
+
+func postTicketNote(ctx *fiber.Ctx) error {
+	id, _ := strconv.Atoi(ctx.Params("id"))
+	err := apollo.AddNote(id, authorName(ctx), ctx.FormValue("text"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+
+	return ctx.Redirect("/tickets/"+strconv.Itoa(id), fiber.StatusSeeOther)
+}
@@ -0,0 +1,234 @@
+// Copyright (C) 2023 Tycho Softworks.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/session"
+
+	"apollo/internal"
+	"gitlab.com/tychosoft/service"
+)
+
+var sessions = session.New(session.Config{
+	Expiration:     30 * time.Minute,
+	KeyLookup:      "cookie:apollo_session",
+	CookieHTTPOnly: true,
+	CookieSameSite: "Lax",
+})
+
+// line owner logged into the user page, 0 if none
+func sessionLine(ctx *fiber.Ctx) int {
+	sess, err := sessions.Get(ctx)
+	if err != nil {
+		return 0
+	}
+
+	id, ok := sess.Get("line").(int)
+	if !ok {
+		return 0
+	}
+	return id
+}
+
+func lineUser(ctx *fiber.Ctx) error {
+	id := sessionLine(ctx)
+	if id == 0 {
+		return ctx.Redirect("/user", fiber.StatusSeeOther)
+	}
+
+	ctx.Locals("userID", id)
+	return ctx.Next()
+}
+
+func viewUser(ctx *fiber.Ctx) error {
+	id := sessionLine(ctx)
+	line := apollo.GetLine(id)
+	lock.RLock()
+	defer lock.RUnlock()
+	if line == nil {
+		err := ctx.Render("user-login", fiber.Map{
+			"page": config,
+		})
+		if err != nil {
+			service.Error(err)
+		}
+		return err
+	}
+
+	err := ctx.Render("user", fiber.Map{
+		"page":     config,
+		"Id":       id,
+		"Line":     line,
+		"Contacts": apollo.PersonalContacts(id),
+		"Tickets":  apollo.GetTickets("", id),
+	})
+	if err != nil {
+		service.Error(err)
+	}
+	return err
+}
+
+func postUserLogin(ctx *fiber.Ctx) error {
+	ip := ctx.IP()
+	if loginLocked(ip) {
+		service.Warn("user login refused for locked out ", ip)
+		return ctx.Status(fiber.StatusTooManyRequests).SendString("Too many failed logins, try again later")
+	}
+
+	ext := ctx.FormValue("ext")
+	id, _ := strconv.Atoi(ext)
+	line := apollo.GetLine(id)
+	if line == nil || !apollo.ExistsLine(id) || !apollo.VerifySecret(ext, line, ctx.FormValue("pass")) {
+		loginFailed(ip, "line "+strconv.Itoa(id))
+		return ctx.Status(fiber.StatusUnauthorized).SendString("Invalid extension or password")
+	}
+
+	loginPassed(ip)
+
+	sess, err := sessions.Get(ctx)
+	if err == nil {
+		err = sess.Regenerate()
+	}
+	if err == nil {
+		sess.Set("line", id)
+		err = sess.Save()
+	}
+	if err != nil {
+		service.Error(err)
+		return ctx.Status(fiber.StatusInternalServerError).SendString("Cannot start session")
+	}
+
+	service.Info("user ", id, " logged in from ", ip)
+	return ctx.Redirect("/user", fiber.StatusSeeOther)
+}
+
+func postUserLogout(ctx *fiber.Ctx) error {
+	value, err := endSession(ctx, "line")
+	if err != nil {
+		service.Error(err)
+	}
+
+	if id, ok := value.(int); ok {
+		service.Info("user ", id, " logged out")
+	}
+	return ctx.Redirect("/user", fiber.StatusSeeOther)
+}
+
+func postUserProfile(ctx *fiber.Ctx) error {
+	id := ctx.Locals("userID").(int)
+	line := apollo.GetLine(id)
+	if line == nil || !line.Editable {
+		return ctx.Status(fiber.StatusBadRequest).SendString("Line settings not changeable")
+	}
+
+	save := apollo.SavedLine(id)
+	display := strings.TrimSpace(ctx.FormValue("display"))
+	if display == "" {
+		return ctx.Status(fiber.StatusBadRequest).SendString("Please enter a display name.")
+	}
+
+	if display != line.Display {
+		save.Display = display
+	}
+
+	if ctx.FormValue("email") != line.EMail {
+		save.EMail = strings.TrimSpace(ctx.FormValue("email"))
+	}
+
+	err := apollo.UpdateLine(id, save)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGHUP)
+	return ctx.Redirect("/user", fiber.StatusSeeOther)
+}
+
+func postUserPasswd(ctx *fiber.Ctx) error {
+	id := ctx.Locals("userID").(int)
+	ext := strconv.Itoa(id)
+	line := apollo.GetLine(id)
+	if line == nil || !line.Editable {
+		return ctx.Status(fiber.StatusBadRequest).SendString("Line settings not changeable")
+	}
+
+	if !apollo.VerifySecret(ext, line, ctx.FormValue("current")) {
+		return ctx.Status(fiber.StatusBadRequest).SendString("Current password is wrong.")
+	}
+
+	passwd := ctx.FormValue("pass")
+	if len(passwd) == 0 {
+		return ctx.Status(fiber.StatusBadRequest).SendString("Password not set.")
+	}
+
+	if passwd != ctx.FormValue("verify") {
+		return ctx.Status(fiber.StatusBadRequest).SendString("Password does not match verify.")
+	}
+
+	save := apollo.SavedLine(id)
+	setDigests(save, ext, passwd)
+	err := apollo.UpdateLine(id, save)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+
+	service.Info("user ", id, " changed password")
+	syscall.Kill(syscall.Getpid(), syscall.SIGHUP)
+	return ctx.Redirect("/user", fiber.StatusSeeOther)
+}
+
+func postUserContact(ctx *fiber.Ctx) error {
+	contact := contactForm(ctx, 0)
+	contact.Line = ctx.Locals("userID").(int)
+	_, err := apollo.UpdateContact(contact)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+	return ctx.Redirect("/user", fiber.StatusSeeOther)
+}
+
+func deleteUserContact(ctx *fiber.Ctx) error {
+	id, _ := strconv.Atoi(ctx.Params("id"))
+	contact := apollo.GetContact(id)
+	if contact == nil || contact.Line != ctx.Locals("userID").(int) {
+		return ctx.Status(fiber.StatusNotFound).SendString("Contact is invalid")
+	}
+
+	err := apollo.RemoveContact(id)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+	return ctx.Redirect("/user", fiber.StatusSeeOther)
+}
+
+// service requests from line owners become tickets for the line
+func postUserRequest(ctx *fiber.Ctx) error {
+	id := ctx.Locals("userID").(int)
+	author := "line " + strconv.Itoa(id)
+	ticket, err := apollo.NewTicket(ctx.FormValue("title"), ctx.FormValue("text"), author, id)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+
+	service.Info("ticket ", ticket.Id, " opened by ", author)
+	return ctx.Redirect("/user", fiber.StatusSeeOther)
+}
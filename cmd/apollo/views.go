@@ -16,7 +16,10 @@
 package main
 
 import (
+	"fmt"
 	"sort"
+	"strconv"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 
@@ -37,13 +40,42 @@ func viewPing(ctx *fiber.Ctx) error {
 	return err
 }
 
+func formatUptime(uptime time.Duration) string {
+	minutes := int(uptime.Minutes())
+	days := minutes / 1440
+	hours := (minutes % 1440) / 60
+	minutes %= 60
+	if days > 0 {
+		return fmt.Sprintf("%dd %dh %dm", days, hours, minutes)
+	}
+	return fmt.Sprintf("%dh %dm", hours, minutes)
+}
+
 func viewMain(ctx *fiber.Ctx) error {
-	// will probably have some info pulled from status
-	// will have button for change password, to add line, add group, etc...
+	var warnings []string
+	uptime := "unknown"
+	sys := apollo.GetSystem()
+	if sys != nil {
+		uptime = formatUptime(time.Since(sys.Started))
+		if sys.Realm != apollo.Realm {
+			warnings = append(warnings, "Coventry realm "+sys.Realm+" differs from "+apollo.Realm)
+		}
+		if sys.Digest != apollo.Algorithm {
+			warnings = append(warnings, "Coventry digest "+sys.Digest+" differs from "+apollo.Algorithm)
+		}
+	} else {
+		warnings = append(warnings, "Coventry registry is not available")
+	}
+
 	lock.RLock()
 	defer lock.RUnlock()
 	err := ctx.Render("main", fiber.Map{
-		"page": config,
+		"page":       config,
+		"system":     sys,
+		"uptime":     uptime,
+		"lines":      apollo.CountLines(),
+		"registered": apollo.CountRegistered(),
+		"warnings":   warnings,
 	})
 	if err != nil {
 		service.Error(err)
@@ -79,12 +111,50 @@ func viewLines(ctx *fiber.Ctx) error {
 	return err
 }
 
+func viewCalls(ctx *fiber.Ctx) error {
+	calls := apollo.GetCalls()
+	sort.Slice(calls, func(i, j int) bool {
+		return calls[i].Created.Before(calls[j].Created)
+	})
+
+	lock.RLock()
+	defer lock.RUnlock()
+	err := ctx.Render("calls", fiber.Map{
+		"page":  config,
+		"items": calls,
+	})
+	if err != nil {
+		service.Error(err)
+	}
+	return err
+}
+
 func viewGroups(ctx *fiber.Ctx) error {
-	// will probably pass group config, have manipulation functions
+	type Item struct {
+		Id      int
+		Group   *apollo.Group
+		Members string
+	}
+
+	groups := apollo.GetGroups()
+	items := make([]Item, 0, len(groups))
+	for key, value := range groups {
+		id, err := strconv.Atoi(key)
+		if err != nil {
+			continue
+		}
+		items = append(items, Item{id, value, joinMembers(value.Members)})
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Id < items[j].Id
+	})
+
 	lock.RLock()
 	defer lock.RUnlock()
 	err := ctx.Render("groups", fiber.Map{
-		"page": config,
+		"page":  config,
+		"items": items,
 	})
 	if err != nil {
 		service.Error(err)
@@ -93,11 +163,11 @@ func viewGroups(ctx *fiber.Ctx) error {
 }
 
 func viewContacts(ctx *fiber.Ctx) error {
-	// will form list from config, have print option
 	lock.RLock()
 	defer lock.RUnlock()
 	err := ctx.Render("contacts", fiber.Map{
-		"page": config,
+		"page":  config,
+		"items": apollo.GetContacts(),
 	})
 	if err != nil {
 		service.Error(err)
@@ -21,6 +21,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/template/html/v2"
@@ -75,3 +76,17 @@ func TestViewMain(t *testing.T) {
 		t.Errorf("Expected to contain %q, but got %q", expected, string(body))
 	}
 }
+
+func TestFormatUptime(t *testing.T) {
+	tests := map[time.Duration]string{
+		59 * time.Second:          "0h 0m",
+		3725 * time.Second:        "1h 2m",
+		(26*60 + 5) * time.Minute: "1d 2h 5m",
+	}
+	for uptime, expected := range tests {
+		actual := formatUptime(uptime)
+		if actual != expected {
+			t.Errorf("Expected formatUptime(%v) to return %q, but got %q", uptime, expected, actual)
+		}
+	}
+}
@@ -9,6 +9,7 @@ require (
 	github.com/gofiber/template/html/v2 v2.1.2
 	github.com/ipinfo/go/v2 v2.10.0
 	gitlab.com/tychosoft/service v1.2.0
+	golang.org/x/crypto v0.31.0
 	gopkg.in/ini.v1 v1.67.0
 )
 
@@ -24,8 +25,10 @@ require (
 	github.com/mattn/go-isatty v0.0.20 // indirect
 	github.com/mattn/go-runewidth v0.0.16 // indirect
 	github.com/patrickmn/go-cache v2.1.0+incompatible // indirect
+	github.com/philhofer/fwd v1.1.2 // indirect
 	github.com/rivo/uniseg v0.4.7 // indirect
 	github.com/stretchr/testify v1.10.0 // indirect
+	github.com/tinylib/msgp v1.1.8 // indirect
 	github.com/valyala/bytebufferpool v1.0.0 // indirect
 	github.com/valyala/fasthttp v1.58.0 // indirect
 	github.com/valyala/tcplisten v1.0.0 // indirect
@@ -0,0 +1,134 @@
+// Copyright (C) 2023 Tycho Softworks.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package apollo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+func isPolicy(name string) bool {
+	if len(name) < 1 || len(name) > 32 || name[0] < 'a' || name[0] > 'z' {
+		return false
+	}
+
+	for _, ch := range name {
+		if (ch < 'a' || ch > 'z') && (ch < '0' || ch > '9') && ch != '-' && ch != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+func GetAccess() map[string]*Group {
+	policies := make(map[string]*Group)
+	lock.RLock()
+	defer lock.RUnlock()
+	section := coventryConfig.Section("access")
+	for _, key := range section.Keys() {
+		id := key.Name()
+		policy := fetchPolicy(id)
+		if policy != nil {
+			policies[id] = policy
+		}
+	}
+	return policies
+}
+
+func ExistsPolicy(name string) bool {
+	lock.RLock()
+	defer lock.RUnlock()
+	return coventryConfig.Section("access").HasKey(name)
+}
+
+func UpdatePolicy(name string, members []int) error {
+	name = strings.Clone(strings.ToLower(name))
+	if !isPolicy(name) {
+		return fmt.Errorf("invalid policy name")
+	}
+
+	var list []string
+	lock.Lock()
+	defer lock.Unlock()
+	if coventryCustom.Section("access").HasKey(name) {
+		return fmt.Errorf("custom policies not changeable")
+	}
+
+	for _, member := range members {
+		if !coventryConfig.HasSection(strconv.Itoa(member)) {
+			return fmt.Errorf("line %d does not exist", member)
+		}
+		list = append(list, strconv.Itoa(member))
+	}
+
+	SetConfig(coventryUpdate.Section("access"), name, strings.Join(list, ","))
+	return saveUpdate()
+}
+
+func RemovePolicy(name string) error {
+	lock.Lock()
+	defer lock.Unlock()
+	if coventryCustom.Section("access").HasKey(name) {
+		return fmt.Errorf("custom policies not changeable")
+	}
+
+	if !coventryUpdate.Section("access").HasKey(name) {
+		return fmt.Errorf("policy %s does not exist", name)
+	}
+
+	for _, section := range coventryConfig.Sections() {
+		key := section.Name()
+		if key < "10" || key > "89" {
+			continue
+		}
+		if GetConfig(section, "acl", "") == name {
+			return fmt.Errorf("policy used by line %s", key)
+		}
+	}
+
+	coventryUpdate.Section("access").DeleteKey(name)
+	return saveUpdate()
+}
+
+func UpdateACL(extension int, policy string) error {
+	if extension < 10 || extension > 89 {
+		return fmt.Errorf("invalid line number")
+	}
+
+	id := strconv.Itoa(extension)
+	policy = strings.Clone(strings.ToLower(policy))
+	lock.Lock()
+	defer lock.Unlock()
+	if !coventryConfig.HasSection(id) {
+		return fmt.Errorf("line %s does not exist", id)
+	}
+
+	if len(coventryCustom.Section(id).Keys()) > 0 {
+		return fmt.Errorf("custom lines not changeable")
+	}
+
+	section := coventryUpdate.Section(id)
+	if len(policy) > 0 {
+		if !coventryConfig.Section("access").HasKey(policy) {
+			return fmt.Errorf("policy %s does not exist", policy)
+		}
+		SetConfig(section, "acl", policy)
+	} else {
+		section.DeleteKey("acl")
+	}
+	return saveUpdate()
+}
@@ -0,0 +1,102 @@
+// Copyright (C) 2023 Tycho Softworks.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package apollo
+
+import (
+	"testing"
+)
+
+func TestAccess(t *testing.T) {
+	emptyConfig(t)
+	coventryConfig.Section("10")
+	coventryConfig.Section("11")
+	coventryConfig.Section("12")
+	coventryCustom.Section("access").Key("fixed").SetValue("10")
+	coventryCustom.Section("12").Key("display").SetValue("Lobby")
+
+	for _, name := range []string{"", "1st", "-local", "has space", "a.b", "abcdefghijklmnopqrstuvwxyz0123456"} {
+		if err := UpdatePolicy(name, []int{10}); err == nil {
+			t.Errorf("Expected error for policy name %q", name)
+		}
+	}
+
+	if err := UpdatePolicy("fixed", []int{10}); err == nil {
+		t.Errorf("Expected error for custom policy")
+	}
+
+	if err := UpdatePolicy("local", []int{10, 13}); err == nil {
+		t.Errorf("Expected error for missing member")
+	}
+
+	if err := UpdatePolicy("Local", []int{10, 11}); err != nil {
+		t.Fatal(err)
+	}
+
+	if coventryUpdate.Section("access").Key("local").String() != "10,11" {
+		t.Errorf("Policy not saved in lower case")
+	}
+
+	// changes are visible once coventry config reloads
+	coventryConfig.Section("access").Key("local").SetValue("10,11")
+
+	if err := UpdateACL(9, "local"); err == nil {
+		t.Errorf("Expected error for invalid line")
+	}
+
+	if err := UpdateACL(13, "local"); err == nil {
+		t.Errorf("Expected error for missing line")
+	}
+
+	if err := UpdateACL(12, "local"); err == nil {
+		t.Errorf("Expected error for custom line")
+	}
+
+	if err := UpdateACL(10, "remote"); err == nil {
+		t.Errorf("Expected error for missing policy")
+	}
+
+	if err := UpdateACL(10, "LOCAL"); err != nil {
+		t.Fatal(err)
+	}
+
+	if coventryUpdate.Section("10").Key("acl").String() != "local" {
+		t.Errorf("Line acl not saved")
+	}
+
+	// policy in use by a line cannot be removed
+	coventryConfig.Section("10").Key("acl").SetValue("local")
+	if err := RemovePolicy("local"); err == nil {
+		t.Errorf("Expected error for policy in use")
+	}
+
+	if err := UpdateACL(10, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if coventryUpdate.Section("10").HasKey("acl") {
+		t.Errorf("Line acl not cleared")
+	}
+
+	coventryConfig.Section("access").Key("system").SetValue("10")
+	if err := RemovePolicy("system"); err == nil {
+		t.Errorf("Expected error for policy only in coventry.conf")
+	}
+
+	coventryConfig.Section("10").DeleteKey("acl")
+	if err := RemovePolicy("local"); err != nil || coventryUpdate.Section("access").HasKey("local") {
+		t.Errorf("Policy not removed: %v", err)
+	}
+}
@@ -0,0 +1,359 @@
+// Copyright (C) 2023 Tycho Softworks.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package apollo
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.com/tychosoft/service"
+)
+
+// completed call detail record
+type Record struct {
+	Id        uint64    `json:"id"`
+	Start     time.Time `json:"start"`
+	Duration  int64     `json:"duration"`
+	Caller    string    `json:"caller"`
+	Dialed    string    `json:"dialed"`
+	Remote    string    `json:"remote"`
+	Direction string    `json:"direction"`
+	Line      int       `json:"line"`
+	Country   string    `json:"country,omitempty"`
+}
+
+type activeCall struct {
+	call      *Call
+	connected time.Time
+	closed    time.Time
+}
+
+var (
+	cdrLock     sync.Mutex
+	cdrPath     string
+	cdrDays     = 90
+	cdrLimit    = 50000
+	cdrRecords  []*Record
+	cdrPruned   time.Time
+	activeCalls = make(map[uint64]*activeCall)
+)
+
+// user part of a sip uri or plain number
+func userPart(uri string) string {
+	uri = strings.TrimPrefix(uri, "<")
+	uri = strings.TrimPrefix(uri, "sips:")
+	uri = strings.TrimPrefix(uri, "sip:")
+	if pos := strings.IndexAny(uri, "@;>"); pos >= 0 {
+		uri = uri[:pos]
+	}
+	return uri
+}
+
+// local extension involved in a call, 0 if none
+func callLine(call *Call) int {
+	first, second := call.Caller, call.Dialed
+	if call.Type == "inbound" {
+		first, second = second, first
+	}
+
+	for _, uri := range []string{first, second} {
+		user := userPart(uri)
+		if isLine(user) {
+			return int(user[0]-'0')*10 + int(user[1]-'0')
+		}
+	}
+	return 0
+}
+
+func finishCall(active *activeCall, now time.Time) *Record {
+	call := active.call
+	record := &Record{
+		Id:        call.Id,
+		Start:     call.Created,
+		Caller:    call.Caller,
+		Dialed:    call.Dialed,
+		Remote:    call.Remote,
+		Direction: call.Type,
+		Line:      callLine(call),
+	}
+
+	if location := CachedLocation(RemoteHost(call.Remote)); location != nil {
+		record.Country = location.Country
+	}
+
+	if !active.connected.IsZero() {
+		ended := now
+		if !active.closed.IsZero() {
+			ended = active.closed
+		}
+		record.Duration = int64(ended.Sub(active.connected).Seconds())
+	}
+	return record
+}
+
+// diff active calls against the last sample, returning completed calls
+func trackCalls(calls []*Call, now time.Time) []*Record {
+	var records []*Record
+	seen := make(map[uint64]bool)
+	for _, call := range calls {
+		active := activeCalls[call.Id]
+		if active != nil && !active.call.Created.Equal(call.Created) {
+			records = append(records, finishCall(active, now))
+			active = nil
+		}
+
+		// calls already connected when first seen count from creation
+		if active == nil {
+			active = &activeCall{}
+			if call.State == "connected" {
+				active.connected = call.Created
+			}
+			activeCalls[call.Id] = active
+		}
+
+		active.call = call
+		seen[call.Id] = true
+		switch call.State {
+		case "connected":
+			if active.connected.IsZero() {
+				active.connected = now
+			}
+		case "closing":
+			if !active.connected.IsZero() && active.closed.IsZero() {
+				active.closed = now
+			}
+		}
+	}
+
+	for id, active := range activeCalls {
+		if !seen[id] {
+			records = append(records, finishCall(active, now))
+			delete(activeCalls, id)
+		}
+	}
+	return records
+}
+
+func saveRecords(records []*Record) error {
+	tmp := cdrPath + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0640)
+	if err != nil {
+		return err
+	}
+
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	for _, record := range records {
+		err = encoder.Encode(record)
+		if err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = writer.Flush()
+	}
+	file.Close()
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, cdrPath)
+}
+
+func appendRecords(records []*Record) error {
+	file, err := os.OpenFile(cdrPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0640)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+	encoder := json.NewEncoder(file)
+	for _, record := range records {
+		err = encoder.Encode(record)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// drop records past retention, caller holds cdrLock
+func pruneRecords(now time.Time) {
+	cdrPruned = now
+	first := 0
+	if cdrDays > 0 {
+		cutoff := now.AddDate(0, 0, -cdrDays)
+		for first < len(cdrRecords) && cdrRecords[first].Start.Before(cutoff) {
+			first++
+		}
+	}
+
+	if cdrLimit > 0 && len(cdrRecords)-first > cdrLimit {
+		first = len(cdrRecords) - cdrLimit
+	}
+
+	if first == 0 {
+		return
+	}
+
+	cdrRecords = append([]*Record(nil), cdrRecords[first:]...)
+	if len(cdrPath) > 0 {
+		err := saveRecords(cdrRecords)
+		if err != nil {
+			service.Error(err)
+		}
+	}
+	Debug(3, "pruned ", first, " call records")
+}
+
+// open call accounting store with retention in days and record limit
+func Accounting(path string, days, limit int) error {
+	cdrLock.Lock()
+	defer cdrLock.Unlock()
+	cdrPath = path
+	cdrDays = days
+	cdrLimit = limit
+	cdrRecords = nil
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		record := &Record{}
+		if json.Unmarshal(scanner.Bytes(), record) == nil {
+			cdrRecords = append(cdrRecords, record)
+		}
+	}
+	pruneRecords(time.Now())
+	return scanner.Err()
+}
+
+// sample the call table and store completed calls
+func SampleCalls() {
+	calls := GetCalls()
+	now := time.Now()
+	cdrLock.Lock()
+	defer cdrLock.Unlock()
+	records := trackCalls(calls, now)
+	if len(records) > 0 {
+		cdrRecords = append(cdrRecords, records...)
+		if len(cdrPath) > 0 {
+			err := appendRecords(records)
+			if err != nil {
+				service.Error(err)
+			}
+		}
+	}
+
+	if now.Sub(cdrPruned) > time.Hour || (cdrLimit > 0 && len(cdrRecords) > cdrLimit+cdrLimit/10) {
+		pruneRecords(now)
+	}
+}
+
+// newest records first, with total count for paging
+func GetRecords(offset, count int) ([]*Record, int) {
+	cdrLock.Lock()
+	defer cdrLock.Unlock()
+	total := len(cdrRecords)
+	records := make([]*Record, 0, count)
+	for pos := total - offset - 1; pos >= 0 && len(records) < count; pos-- {
+		records = append(records, cdrRecords[pos])
+	}
+	return records, total
+}
+
+type UsageFilter struct {
+	From      time.Time
+	To        time.Time
+	Line      int
+	Direction string
+	Monthly   bool
+}
+
+// call totals for a line and direction in a daily or monthly period
+type Usage struct {
+	Period    string `json:"period"`
+	Line      int    `json:"line"`
+	Direction string `json:"direction"`
+	Calls     int    `json:"calls"`
+	Seconds   int64  `json:"seconds"`
+	Minutes   int64  `json:"minutes"`
+}
+
+func (filter *UsageFilter) match(record *Record) bool {
+	if !filter.From.IsZero() && record.Start.Before(filter.From) {
+		return false
+	}
+	if !filter.To.IsZero() && !record.Start.Before(filter.To) {
+		return false
+	}
+	if filter.Line != 0 && record.Line != filter.Line {
+		return false
+	}
+	return filter.Direction == "" || filter.Direction == record.Direction
+}
+
+// totals sorted by period, line, and direction; minutes are rounded up per call
+func GetUsage(filter *UsageFilter) []*Usage {
+	layout := "2006-01-02"
+	if filter.Monthly {
+		layout = "2006-01"
+	}
+
+	totals := make(map[string]*Usage)
+	cdrLock.Lock()
+	for _, record := range cdrRecords {
+		if !filter.match(record) {
+			continue
+		}
+
+		period := record.Start.Local().Format(layout)
+		key := fmt.Sprintf("%s/%02d/%s", period, record.Line, record.Direction)
+		usage := totals[key]
+		if usage == nil {
+			usage = &Usage{Period: period, Line: record.Line, Direction: record.Direction}
+			totals[key] = usage
+		}
+		usage.Calls++
+		usage.Seconds += record.Duration
+		usage.Minutes += (record.Duration + 59) / 60
+	}
+	cdrLock.Unlock()
+
+	keys := make([]string, 0, len(totals))
+	for key := range totals {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	usage := make([]*Usage, 0, len(keys))
+	for _, key := range keys {
+		usage = append(usage, totals[key])
+	}
+	return usage
+}
@@ -0,0 +1,95 @@
+// Copyright (C) 2023 Tycho Softworks.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package apollo
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCallLine(t *testing.T) {
+	tests := []struct {
+		call     Call
+		expected int
+	}{
+		{Call{Caller: "sip:10@pbx.local", Dialed: "5551212", Type: "outgoing"}, 10},
+		{Call{Caller: "5551212", Dialed: "<sip:22@pbx.local>", Type: "inbound"}, 22},
+		{Call{Caller: "11", Dialed: "12", Type: "local"}, 11},
+		{Call{Caller: "5551212", Dialed: "100", Type: "inbound"}, 0},
+	}
+	for _, test := range tests {
+		actual := callLine(&test.call)
+		if actual != test.expected {
+			t.Errorf("Expected callLine(%v) to return %d, but got %d", test.call, test.expected, actual)
+		}
+	}
+}
+
+func TestTrackCalls(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	call := &Call{Id: 1, Created: start, Caller: "10", Dialed: "5551212", State: "ringing", Type: "outgoing"}
+	if records := trackCalls([]*Call{call}, start); len(records) != 0 {
+		t.Fatalf("Expected no completed calls, got %d", len(records))
+	}
+
+	connected := *call
+	connected.State = "connected"
+	trackCalls([]*Call{&connected}, start.Add(5*time.Second))
+	records := trackCalls(nil, start.Add(65*time.Second))
+	if len(records) != 1 {
+		t.Fatalf("Expected one completed call, got %d", len(records))
+	}
+
+	record := records[0]
+	if record.Duration != 60 || record.Line != 10 || record.Direction != "outgoing" {
+		t.Errorf("Unexpected record %+v", record)
+	}
+
+	if len(activeCalls) != 0 {
+		t.Errorf("Expected no active calls, got %d", len(activeCalls))
+	}
+}
+
+func TestGetUsage(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.Local)
+	cdrLock.Lock()
+	cdrRecords = []*Record{
+		{Start: start, Duration: 61, Line: 10, Direction: "outgoing"},
+		{Start: start.Add(time.Hour), Duration: 30, Line: 10, Direction: "outgoing"},
+		{Start: start.AddDate(0, 0, 1), Duration: 120, Line: 10, Direction: "inbound"},
+		{Start: start.AddDate(0, 1, 0), Duration: 10, Line: 11, Direction: "local"},
+	}
+	cdrLock.Unlock()
+	defer func() { cdrRecords = nil }()
+
+	usage := GetUsage(&UsageFilter{Line: 10})
+	if len(usage) != 2 {
+		t.Fatalf("Expected 2 daily totals, got %d", len(usage))
+	}
+	if usage[0].Period != "2024-03-01" || usage[0].Calls != 2 || usage[0].Seconds != 91 || usage[0].Minutes != 3 {
+		t.Errorf("Unexpected usage %+v", usage[0])
+	}
+
+	usage = GetUsage(&UsageFilter{Monthly: true, From: start.AddDate(0, 0, 1)})
+	if len(usage) != 2 || usage[0].Period != "2024-03" || usage[1].Line != 11 {
+		t.Errorf("Unexpected monthly usage %+v", usage)
+	}
+
+	usage = GetUsage(&UsageFilter{Direction: "local", To: start.AddDate(0, 1, 0)})
+	if len(usage) != 0 {
+		t.Errorf("Expected no usage before end, got %d", len(usage))
+	}
+}
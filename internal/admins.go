@@ -0,0 +1,447 @@
+// Copyright (C) 2023 Tycho Softworks.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package apollo
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/ini.v1"
+
+	"gitlab.com/tychosoft/service"
+)
+
+const (
+	RoleAdmin    = "admin"
+	RoleOperator = "operator"
+	RoleAuditor  = "auditor"
+)
+
+// web admin account, the primary one is the setup account in [server]
+type Admin struct {
+	Username string `json:"username"`
+	Role     string `json:"role"`
+	Disabled bool   `json:"disabled"`
+	Primary  bool   `json:"primary"`
+	Editable bool   `json:"-"`
+}
+
+func roleRank(role string) int {
+	switch role {
+	case RoleAdmin:
+		return 3
+	case RoleOperator:
+		return 2
+	case RoleAuditor:
+		return 1
+	}
+	return 0
+}
+
+// true if role grants at least the required role
+func HasRole(role, required string) bool {
+	rank := roleRank(role)
+	return rank > 0 && rank >= roleRank(required)
+}
+
+func isUsername(name string) bool {
+	if len(name) < 1 || len(name) > 32 {
+		return false
+	}
+
+	for _, ch := range name {
+		switch {
+		case ch >= 'a' && ch <= 'z', ch >= '0' && ch <= '9':
+		case ch == '-' || ch == '_' || ch == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// basic auth checks every request, remember the last good login so we do
+// not pay for a full password hash each time.
+var (
+	verified     = make(map[string]string)
+	verifiedLock sync.Mutex
+)
+
+func verifiedKey(user, stored, pass string) string {
+	digest := sha256.New()
+	digest.Write([]byte(user + "\x00" + stored + "\x00" + pass))
+	return hex.EncodeToString(digest.Sum(nil))
+}
+
+// stored password of an account, disabled accounts are prefixed with !
+func adminPassword(user string) (string, bool) {
+	server := coventryConfig.Section("server")
+	if user == GetConfig(server, "webadmin", "admin") {
+		return GetConfig(server, "webpass", ""), true
+	}
+
+	key, err := coventryConfig.Section("webusers").GetKey(user)
+	if err != nil || len(user) < 1 {
+		return "", false
+	}
+	return key.Value(), true
+}
+
+// pending changes may not be reloaded into coventry config yet
+func updatedPassword(user string) string {
+	key, err := coventryUpdate.Section("webusers").GetKey(user)
+	if err == nil {
+		return key.Value()
+	}
+
+	stored, _ := adminPassword(user)
+	return stored
+}
+
+func adminRole(user string) string {
+	if user == GetConfig(coventryConfig.Section("server"), "webadmin", "admin") {
+		return RoleAdmin
+	}
+	return GetConfig(coventryConfig.Section("webroles"), user, RoleAuditor)
+}
+
+// check web admin credentials, returns role of an enabled account
+func VerifyAdmin(user, pass string) (string, bool) {
+	lock.RLock()
+	stored, found := adminPassword(user)
+	role := adminRole(user)
+	lock.RUnlock()
+	if !found || len(stored) < 1 || strings.HasPrefix(stored, "!") {
+		return "", false
+	}
+
+	key := verifiedKey(user, stored, pass)
+	verifiedLock.Lock()
+	known := verified[user]
+	verifiedLock.Unlock()
+	if len(known) > 0 && sameSecret(known, key) {
+		return role, true
+	}
+
+	ok, upgrade := CheckPassword(stored, user, pass)
+	if !ok {
+		return "", false
+	}
+
+	if upgrade {
+		stored = upgradeAdmin(user, stored, pass)
+		key = verifiedKey(user, stored, pass)
+	}
+
+	verifiedLock.Lock()
+	verified[strings.Clone(user)] = key
+	verifiedLock.Unlock()
+	return role, true
+}
+
+// re-hash a legacy or weaker password after a successful login
+func upgradeAdmin(user, stored, pass string) string {
+	hash, err := HashPassword(pass)
+	if err != nil {
+		service.Error(err)
+		return stored
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	current, _ := adminPassword(user)
+	if current != stored {
+		return current
+	}
+
+	group, id := "webusers", user
+	if user == GetConfig(coventryConfig.Section("server"), "webadmin", "admin") {
+		group, id = "server", "webpass"
+	}
+
+	if coventryCustom.Section(group).HasKey(id) {
+		return stored
+	}
+
+	SetConfig(coventryUpdate.Section(group), strings.Clone(id), hash)
+	SetConfig(coventryConfig.Section(group), strings.Clone(id), hash)
+	err = saveUpdate()
+	if err != nil {
+		service.Error(err)
+		return stored
+	}
+
+	service.Info("upgraded password hash of admin ", user)
+	return hash
+}
+
+// role of an enabled account, empty if unknown or disabled
+func AdminRole(user string) string {
+	lock.RLock()
+	defer lock.RUnlock()
+	stored, found := adminPassword(user)
+	if !found || strings.HasPrefix(stored, "!") {
+		return ""
+	}
+	return adminRole(user)
+}
+
+func GetAdmins() []*Admin {
+	lock.RLock()
+	defer lock.RUnlock()
+	primary := GetConfig(coventryConfig.Section("server"), "webadmin", "admin")
+	admins := []*Admin{{Username: primary, Role: RoleAdmin, Primary: true}}
+	custom := coventryCustom.Section("webusers")
+	for _, key := range coventryConfig.Section("webusers").Keys() {
+		user := key.Name()
+		if user == primary {
+			continue
+		}
+
+		admins = append(admins, &Admin{
+			Username: user,
+			Role:     adminRole(user),
+			Disabled: strings.HasPrefix(key.Value(), "!"),
+			Editable: !custom.HasKey(user),
+		})
+	}
+
+	sort.Slice(admins[1:], func(i, j int) bool {
+		return admins[i+1].Username < admins[j+1].Username
+	})
+	return admins
+}
+
+func GetAdmin(user string) *Admin {
+	for _, admin := range GetAdmins() {
+		if admin.Username == user {
+			return admin
+		}
+	}
+	return nil
+}
+
+func checkRole(role string) error {
+	if roleRank(role) == 0 {
+		return fmt.Errorf("invalid role %s", role)
+	}
+	return nil
+}
+
+// get an additional account that can be changed
+func editableAdmin(user string) error {
+	if user == GetConfig(coventryConfig.Section("server"), "webadmin", "admin") {
+		return fmt.Errorf("primary admin %s cannot be changed here", user)
+	}
+
+	if !coventryConfig.Section("webusers").HasKey(user) {
+		return fmt.Errorf("admin %s does not exist", user)
+	}
+
+	if coventryCustom.Section("webusers").HasKey(user) {
+		return fmt.Errorf("custom admin %s not changeable", user)
+	}
+	return nil
+}
+
+// set the primary setup account
+func SetupAdmin(user, pass string) error {
+	if len(user) < 1 || strings.ContainsAny(user, " \t:") {
+		return fmt.Errorf("invalid admin username")
+	}
+
+	if len(pass) < 1 {
+		return fmt.Errorf("password must not be empty")
+	}
+
+	hash, err := HashPassword(pass)
+	if err != nil {
+		return err
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	section := coventryUpdate.Section("server")
+	SetConfig(section, "webadmin", strings.Clone(user))
+	SetConfig(section, "webpass", hash)
+	return saveUpdate()
+}
+
+// change the password and, for the primary account, the username of an
+// admin after checking their current password.
+func ChangeAdmin(user, current, rename, pass string) error {
+	rename = strings.Clone(rename)
+	if len(pass) < 1 {
+		return fmt.Errorf("password must not be empty")
+	}
+
+	hash, err := HashPassword(pass)
+	if err != nil {
+		return err
+	}
+
+	lock.RLock()
+	stored, found := adminPassword(user)
+	lock.RUnlock()
+	if !found || strings.HasPrefix(stored, "!") {
+		return fmt.Errorf("admin %s does not exist", user)
+	}
+
+	if ok, _ := CheckPassword(stored, user, current); !ok {
+		return fmt.Errorf("current password is wrong")
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	if latest, _ := adminPassword(user); latest != stored {
+		return fmt.Errorf("admin %s changed, please try again", user)
+	}
+
+	server := coventryConfig.Section("server")
+	if user != GetConfig(server, "webadmin", "admin") {
+		if rename != user {
+			return fmt.Errorf("only the primary admin can be renamed")
+		}
+
+		if coventryCustom.Section("webusers").HasKey(user) {
+			return fmt.Errorf("custom admin %s not changeable", user)
+		}
+
+		user = strings.Clone(user)
+		SetConfig(coventryUpdate.Section("webusers"), user, hash)
+		SetConfig(coventryConfig.Section("webusers"), user, hash)
+		return saveUpdate()
+	}
+
+	if len(rename) < 1 || strings.ContainsAny(rename, " \t:") {
+		return fmt.Errorf("invalid admin username")
+	}
+
+	if rename != user && coventryConfig.Section("webusers").HasKey(rename) {
+		return fmt.Errorf("admin %s already exists", rename)
+	}
+
+	custom := coventryCustom.Section("server")
+	if custom.HasKey("webpass") || (rename != user && custom.HasKey("webadmin")) {
+		return fmt.Errorf("custom admin %s not changeable", user)
+	}
+
+	// update merged config too so the change applies before reload
+	for _, section := range []*ini.Section{coventryUpdate.Section("server"), server} {
+		SetConfig(section, "webadmin", rename)
+		SetConfig(section, "webpass", hash)
+	}
+	return saveUpdate()
+}
+
+func NewAdmin(user, pass, role string) error {
+	user = strings.ToLower(strings.Clone(user))
+	if !isUsername(user) {
+		return fmt.Errorf("invalid admin username")
+	}
+
+	if len(pass) < 1 {
+		return fmt.Errorf("password must not be empty")
+	}
+
+	err := checkRole(role)
+	if err != nil {
+		return err
+	}
+
+	hash, err := HashPassword(pass)
+	if err != nil {
+		return err
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	_, found := adminPassword(user)
+	if found {
+		return fmt.Errorf("admin %s already exists", user)
+	}
+
+	SetConfig(coventryUpdate.Section("webusers"), user, hash)
+	SetConfig(coventryUpdate.Section("webroles"), user, strings.Clone(role))
+	return saveUpdate()
+}
+
+func UpdateAdmin(user, role string) error {
+	user = strings.Clone(user)
+	err := checkRole(role)
+	if err != nil {
+		return err
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	err = editableAdmin(user)
+	if err != nil {
+		return err
+	}
+
+	SetConfig(coventryUpdate.Section("webroles"), user, strings.Clone(role))
+	return saveUpdate()
+}
+
+func DisableAdmin(user string, disabled bool) error {
+	user = strings.Clone(user)
+	lock.Lock()
+	defer lock.Unlock()
+	err := editableAdmin(user)
+	if err != nil {
+		return err
+	}
+
+	stored := strings.TrimPrefix(updatedPassword(user), "!")
+	if disabled {
+		stored = "!" + stored
+	}
+
+	SetConfig(coventryUpdate.Section("webusers"), user, stored)
+	return saveUpdate()
+}
+
+// set a new password, a disabled account stays disabled
+func ResetAdmin(user, pass string) error {
+	user = strings.Clone(user)
+	if len(pass) < 1 {
+		return fmt.Errorf("password must not be empty")
+	}
+
+	hash, err := HashPassword(pass)
+	if err != nil {
+		return err
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	err = editableAdmin(user)
+	if err != nil {
+		return err
+	}
+
+	if strings.HasPrefix(updatedPassword(user), "!") {
+		hash = "!" + hash
+	}
+
+	SetConfig(coventryUpdate.Section("webusers"), user, hash)
+	return saveUpdate()
+}
@@ -0,0 +1,130 @@
+// Copyright (C) 2023 Tycho Softworks.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package apollo
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/ini.v1"
+)
+
+func TestAdmins(t *testing.T) {
+	emptyConfig(t)
+
+	// changes are visible once coventry config reloads
+	reload := func() {
+		coventryConfig, _ = ini.LoadSources(ini.LoadOptions{Insensitive: true}, coventrySaveTo)
+	}
+
+	if err := SetupAdmin("root", "secret"); err != nil {
+		t.Fatal(err)
+	}
+
+	reload()
+	if role, ok := VerifyAdmin("root", "secret"); !ok || role != RoleAdmin {
+		t.Errorf("Expected primary admin, got %q %v", role, ok)
+	}
+
+	if err := NewAdmin("Bob", "pass", RoleOperator); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := NewAdmin("eve", "pass", "superuser"); err == nil {
+		t.Errorf("Expected error for invalid role")
+	}
+
+	reload()
+	if err := NewAdmin("bob", "other", RoleAuditor); err == nil {
+		t.Errorf("Expected error for existing admin")
+	}
+
+	if _, ok := VerifyAdmin("bob", "wrong"); ok {
+		t.Errorf("Expected wrong password to fail")
+	}
+
+	if err := DisableAdmin("root", true); err == nil {
+		t.Errorf("Expected error disabling primary admin")
+	}
+
+	if err := DisableAdmin("bob", true); err != nil {
+		t.Fatal(err)
+	}
+
+	reload()
+	if _, ok := VerifyAdmin("bob", "pass"); ok || AdminRole("bob") != "" {
+		t.Errorf("Expected disabled admin to fail")
+	}
+
+	// reset keeps the account disabled
+	ResetAdmin("bob", "newpass")
+	DisableAdmin("bob", false)
+	reload()
+	if role, ok := VerifyAdmin("bob", "newpass"); !ok || role != RoleOperator {
+		t.Errorf("Expected enabled operator, got %q %v", role, ok)
+	}
+
+	admins := GetAdmins()
+	if len(admins) != 2 || !admins[0].Primary || admins[1].Username != "bob" || !admins[1].Editable {
+		t.Errorf("Unexpected admins %+v", admins)
+	}
+
+	// legacy hashes are upgraded in place on login
+	coventryUpdate.Section("server").Key("webpass").SetValue(legacyHash("root", "secret"))
+	coventryConfig.Section("server").Key("webpass").SetValue(legacyHash("root", "secret"))
+	if _, ok := VerifyAdmin("root", "secret"); !ok {
+		t.Errorf("Expected legacy primary admin to verify")
+	}
+
+	reload()
+	if stored, _ := adminPassword("root"); !strings.HasPrefix(stored, "$argon2id$") {
+		t.Errorf("Expected upgraded hash, got %s", stored)
+	}
+
+	if err := ChangeAdmin("root", "wrong", "root", "changed"); err == nil {
+		t.Errorf("Expected error for wrong current password")
+	}
+
+	if err := ChangeAdmin("bob", "newpass", "robert", "changed"); err == nil {
+		t.Errorf("Expected error renaming additional admin")
+	}
+
+	if err := ChangeAdmin("root", "secret", "bob", "changed"); err == nil {
+		t.Errorf("Expected error renaming to existing admin")
+	}
+
+	// applies at once, before coventry config reloads
+	if err := ChangeAdmin("root", "secret", "chief", "changed"); err != nil {
+		t.Fatal(err)
+	}
+
+	if role, ok := VerifyAdmin("chief", "changed"); !ok || role != RoleAdmin {
+		t.Errorf("Expected renamed primary admin, got %q %v", role, ok)
+	}
+
+	if _, ok := VerifyAdmin("root", "secret"); ok {
+		t.Errorf("Expected old primary admin to fail")
+	}
+
+	reload()
+	if _, ok := VerifyAdmin("chief", "changed"); !ok {
+		t.Errorf("Expected renamed primary admin saved")
+	}
+
+	if !HasRole(RoleAdmin, RoleOperator) || HasRole(RoleAuditor, RoleOperator) || HasRole("", RoleAuditor) {
+		t.Errorf("Unexpected role ranking")
+	}
+}
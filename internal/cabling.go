@@ -0,0 +1,350 @@
+// Copyright (C) 2023 Tycho Softworks.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package apollo
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const MaxPorts = 96
+
+// patch panel in the cable plant
+type Panel struct {
+	Name     string `json:"name"`
+	Location string `json:"location"`
+	Ports    int    `json:"ports"`
+}
+
+// wall jack cabled to a panel port; lines are assigned by cabling
+type Jack struct {
+	Id    string `json:"id"`
+	Panel string `json:"panel"`
+	Port  int    `json:"port"`
+	Room  string `json:"room"`
+	Line  int    `json:"-"`
+}
+
+type plant struct {
+	Panels []*Panel `json:"panels"`
+	Jacks  []*Jack  `json:"jacks"`
+}
+
+var (
+	plantLock   sync.Mutex
+	plantPath   string
+	plantPanels = make(map[string]*Panel)
+	plantJacks  = make(map[string]*Jack)
+)
+
+// names used for panels, jacks, rooms, and zones
+func isName(name string) bool {
+	if len(name) < 1 || len(name) > 32 {
+		return false
+	}
+
+	for _, ch := range name {
+		switch {
+		case ch >= 'a' && ch <= 'z', ch >= 'A' && ch <= 'Z', ch >= '0' && ch <= '9':
+		case ch == '-' || ch == '_' || ch == '.' || ch == '/':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// load cable plant store
+func Cabling(path string) error {
+	plantLock.Lock()
+	defer plantLock.Unlock()
+	plantPath = path
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	var saved plant
+	err = json.Unmarshal(data, &saved)
+	if err != nil {
+		return err
+	}
+
+	plantPanels = make(map[string]*Panel)
+	plantJacks = make(map[string]*Jack)
+	for _, panel := range saved.Panels {
+		plantPanels[panel.Name] = panel
+	}
+	for _, jack := range saved.Jacks {
+		plantJacks[jack.Id] = jack
+	}
+	return nil
+}
+
+func savePlant() error {
+	if len(plantPath) == 0 {
+		return nil
+	}
+
+	saved := plant{Panels: sortedPanels(), Jacks: sortedJacks()}
+	data, err := json.MarshalIndent(&saved, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := plantPath + ".tmp"
+	err = os.WriteFile(tmp, data, 0640)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, plantPath)
+}
+
+func sortedPanels() []*Panel {
+	panels := make([]*Panel, 0, len(plantPanels))
+	for _, panel := range plantPanels {
+		copy := *panel
+		panels = append(panels, &copy)
+	}
+	sort.Slice(panels, func(i, j int) bool {
+		return panels[i].Name < panels[j].Name
+	})
+	return panels
+}
+
+func sortedJacks() []*Jack {
+	jacks := make([]*Jack, 0, len(plantJacks))
+	for _, jack := range plantJacks {
+		copy := *jack
+		jacks = append(jacks, &copy)
+	}
+	sort.Slice(jacks, func(i, j int) bool {
+		if jacks[i].Panel != jacks[j].Panel {
+			return jacks[i].Panel < jacks[j].Panel
+		}
+		return jacks[i].Port < jacks[j].Port
+	})
+	return jacks
+}
+
+// lines by cabling, caller holds lock
+func cabledLines() map[string]int {
+	cabled := make(map[string]int)
+	for _, section := range coventryConfig.Sections() {
+		key := section.Name()
+		if !isLine(key) || !section.HasKey("cabling") {
+			continue
+		}
+		id, _ := strconv.Atoi(key)
+		cabled[section.Key("cabling").String()] = id
+	}
+	return cabled
+}
+
+func GetPanels() []*Panel {
+	plantLock.Lock()
+	defer plantLock.Unlock()
+	return sortedPanels()
+}
+
+// jacks by panel and port, with the line cabled to each
+func GetJacks() []*Jack {
+	plantLock.Lock()
+	defer plantLock.Unlock()
+	jacks := sortedJacks()
+	lock.RLock()
+	defer lock.RUnlock()
+	cabled := cabledLines()
+	for _, jack := range jacks {
+		jack.Line = cabled[jack.Id]
+	}
+	return jacks
+}
+
+func UpdatePanel(panel *Panel) error {
+	if !isName(panel.Name) {
+		return fmt.Errorf("invalid panel name")
+	}
+
+	if panel.Ports < 1 || panel.Ports > MaxPorts {
+		return fmt.Errorf("panel ports must be 1 to %d", MaxPorts)
+	}
+
+	plantLock.Lock()
+	defer plantLock.Unlock()
+	for _, jack := range plantJacks {
+		if jack.Panel == panel.Name && jack.Port > panel.Ports {
+			return fmt.Errorf("port %d of %s is cabled to %s", jack.Port, panel.Name, jack.Id)
+		}
+	}
+
+	saved := &Panel{
+		Name:     strings.Clone(panel.Name),
+		Location: strings.Clone(panel.Location),
+		Ports:    panel.Ports,
+	}
+	plantPanels[saved.Name] = saved
+	return savePlant()
+}
+
+func RemovePanel(name string) error {
+	plantLock.Lock()
+	defer plantLock.Unlock()
+	if plantPanels[name] == nil {
+		return fmt.Errorf("panel %s does not exist", name)
+	}
+
+	for _, jack := range plantJacks {
+		if jack.Panel == name {
+			return fmt.Errorf("panel %s still cabled to %s", name, jack.Id)
+		}
+	}
+
+	delete(plantPanels, name)
+	return savePlant()
+}
+
+// add room and zone to dynamic config if missing, caller holds lock
+func syncRoom(room, zone string) bool {
+	changed := false
+	if zone == "" {
+		zone = GetConfig(coventryConfig.Section("rooms"), room, "any")
+	}
+
+	if GetConfig(coventryConfig.Section("rooms"), room, "") != zone {
+		coventryUpdate.Section("rooms").Key(room).SetValue(zone)
+		changed = true
+	}
+
+	if !coventryConfig.Section("zones").HasKey(zone) && !coventryUpdate.Section("zones").HasKey(zone) {
+		coventryUpdate.Section("zones").Key(zone).SetValue(zone)
+		changed = true
+	}
+	return changed
+}
+
+// save jack and keep its room and zone in sync; an empty zone keeps the
+// current zone of an existing room.
+func UpdateJack(jack *Jack, zone string) error {
+	if !isName(jack.Id) {
+		return fmt.Errorf("invalid jack id")
+	}
+
+	if !isName(jack.Room) {
+		return fmt.Errorf("invalid room name")
+	}
+
+	if zone != "" && !isName(zone) {
+		return fmt.Errorf("invalid zone name")
+	}
+
+	plantLock.Lock()
+	defer plantLock.Unlock()
+	panel := plantPanels[jack.Panel]
+	if panel == nil {
+		return fmt.Errorf("panel %s does not exist", jack.Panel)
+	}
+
+	if jack.Port < 1 || jack.Port > panel.Ports {
+		return fmt.Errorf("panel %s has ports 1 to %d", panel.Name, panel.Ports)
+	}
+
+	for _, other := range plantJacks {
+		if other.Id != jack.Id && other.Panel == jack.Panel && other.Port == jack.Port {
+			return fmt.Errorf("port %d of %s is cabled to %s", jack.Port, panel.Name, other.Id)
+		}
+	}
+
+	saved := &Jack{
+		Id:    strings.Clone(jack.Id),
+		Panel: strings.Clone(jack.Panel),
+		Port:  jack.Port,
+		Room:  strings.Clone(jack.Room),
+	}
+	plantJacks[saved.Id] = saved
+
+	err := savePlant()
+	if err != nil {
+		return err
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	changed := syncRoom(saved.Room, strings.Clone(zone))
+
+	// lines cabled to the jack follow it to its room
+	for _, section := range coventryConfig.Sections() {
+		key := section.Name()
+		if isLine(key) && GetConfig(section, "cabling", "") == saved.Id && len(coventryCustom.Section(key).Keys()) == 0 {
+			coventryUpdate.Section(key).Key("room").SetValue(saved.Room)
+			changed = true
+		}
+	}
+
+	if changed {
+		return saveUpdate()
+	}
+	return nil
+}
+
+func RemoveJack(id string) error {
+	plantLock.Lock()
+	defer plantLock.Unlock()
+	if plantJacks[id] == nil {
+		return fmt.Errorf("jack %s does not exist", id)
+	}
+
+	lock.RLock()
+	line := cabledLines()[id]
+	lock.RUnlock()
+	if line != 0 {
+		return fmt.Errorf("jack %s is cabled to line %d", id, line)
+	}
+
+	delete(plantJacks, id)
+	return savePlant()
+}
+
+// validate line cabling against the plant, returning the room of the jack
+func CheckCabling(extension int, cabling string) (string, error) {
+	if cabling == "" {
+		return "", nil
+	}
+
+	plantLock.Lock()
+	defer plantLock.Unlock()
+	jack := plantJacks[cabling]
+	if jack == nil {
+		return "", fmt.Errorf("jack %s does not exist", cabling)
+	}
+
+	lock.RLock()
+	defer lock.RUnlock()
+	line := cabledLines()[cabling]
+	if line != 0 && line != extension {
+		return "", fmt.Errorf("jack %s is cabled to line %d", cabling, line)
+	}
+	return jack.Room, nil
+}
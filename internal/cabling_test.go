@@ -0,0 +1,90 @@
+// Copyright (C) 2023 Tycho Softworks.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package apollo
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestIsName(t *testing.T) {
+	tests := map[string]bool{
+		"2-104A":    true,
+		"idf.1/a":   true,
+		"":          false,
+		"has space": false,
+		"semi;":     false,
+	}
+	for input, expected := range tests {
+		if isName(input) != expected {
+			t.Errorf("Expected isName(%q) to return %v", input, expected)
+		}
+	}
+}
+
+func TestCablePlant(t *testing.T) {
+	dir := t.TempDir()
+	emptyConfig(t)
+	coventryConfig.Section("10").Key("cabling").SetValue("j1")
+
+	if err := Cabling(filepath.Join(dir, "cabling.json")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := UpdatePanel(&Panel{Name: "p1", Ports: 24}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := UpdateJack(&Jack{Id: "j1", Panel: "p1", Port: 25, Room: "lobby"}, ""); err == nil {
+		t.Errorf("Expected error for port past panel")
+	}
+
+	if err := UpdateJack(&Jack{Id: "j1", Panel: "p1", Port: 3, Room: "lobby"}, "front"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := UpdateJack(&Jack{Id: "j2", Panel: "p1", Port: 3, Room: "lobby"}, ""); err == nil {
+		t.Errorf("Expected error for port in use")
+	}
+
+	if coventryUpdate.Section("rooms").Key("lobby").String() != "front" || !coventryUpdate.Section("zones").HasKey("front") {
+		t.Errorf("Expected lobby room in front zone")
+	}
+
+	if coventryUpdate.Section("10").Key("room").String() != "lobby" {
+		t.Errorf("Expected cabled line to follow jack room")
+	}
+
+	if _, err := CheckCabling(11, "j1"); err == nil {
+		t.Errorf("Expected error for jack cabled to another line")
+	}
+
+	if room, err := CheckCabling(10, "j1"); err != nil || room != "lobby" {
+		t.Errorf("Expected line 10 cabling to check, got %q %v", room, err)
+	}
+
+	if err := RemovePanel("p1"); err == nil {
+		t.Errorf("Expected error removing cabled panel")
+	}
+
+	if err := RemoveJack("j1"); err == nil {
+		t.Errorf("Expected error removing jack cabled to a line")
+	}
+
+	if err := Cabling(filepath.Join(dir, "cabling.json")); err != nil || len(GetJacks()) != 1 || GetJacks()[0].Line != 10 {
+		t.Errorf("Expected cable plant to reload with cabled line")
+	}
+}
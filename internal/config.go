@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"os"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -34,6 +35,7 @@ type Line struct {
 	Lines    uint16 `ini:"lines" json:"lines"`
 	Type     string `ini:"type" json:"type"`
 	Location string `ini:"location" json:"location"`
+	Room     string `ini:"room" json:"room"`
 	Cabling  string `ini:"cabling" json:"cabling"`
 	EMail    string `ini:"email" json:"email"`
 	Secret   string `ini:"secret" json:"-"`
@@ -51,9 +53,10 @@ type Line struct {
 }
 
 type Group struct {
-	Display string `json:"display"`
-	Members []int  `json:"members"`
-	Type    string `json:"-"`
+	Display  string `json:"display"`
+	Members  []int  `json:"members"`
+	Type     string `json:"-"`
+	Editable bool   `json:"-"`
 }
 
 var (
@@ -92,6 +95,20 @@ func SaveCoventry() error {
 	return ReloadCoventry()
 }
 
+// save changes, a coventry that is not running is not a save failure
+func saveUpdate() error {
+	err := coventryUpdate.SaveTo(coventrySaveTo)
+	if err != nil {
+		return err
+	}
+
+	err = ReloadCoventry()
+	if err != nil {
+		service.Error(err)
+	}
+	return nil
+}
+
 func defaultConfig() error {
 	var err error = nil
 	var section *ini.Section = nil
@@ -112,13 +129,9 @@ func defaultConfig() error {
 	}
 
 	if err == nil {
-		algo := strings.ToUpper(GetConfig(section, "algorithm", Algorithm))
-		if strings.Contains(algo, "MD5") && strings.Contains(algo, "SHA") {
-			Algorithm = "SHA-256, MD5"
-		} else if strings.Contains(algo, "MD5") {
-			Algorithm = "MD5"
-		} else if strings.Contains(algo, "SHA") {
-			Algorithm = "SHA-256"
+		algo := parseAlgorithm(GetConfig(section, "algorithm", Algorithm))
+		if len(algo) > 0 {
+			Algorithm = algo
 		}
 	}
 
@@ -176,6 +189,18 @@ func defaultConfig() error {
 	return err
 }
 
+func parseAlgorithm(digests string) string {
+	algo := strings.ToUpper(digests)
+	if strings.Contains(algo, "MD5") && strings.Contains(algo, "SHA") {
+		return "SHA-256, MD5"
+	} else if strings.Contains(algo, "MD5") {
+		return "MD5"
+	} else if strings.Contains(algo, "SHA") {
+		return "SHA-256"
+	}
+	return ""
+}
+
 func SetConfig(section *ini.Section, id string, value string) {
 	key, err := section.GetKey(id)
 	if err == nil {
@@ -314,7 +339,7 @@ func GetLines() map[int]*Line {
 
 	for _, section := range coventryConfig.Sections() {
 		key := section.Name()
-		if key < "10" || key > "89" {
+		if !isLine(key) {
 			continue
 		}
 		id, _ := strconv.Atoi(key)
@@ -367,6 +392,7 @@ func fetchGroup(id string) *Group {
 	}
 
 	group.Type = "group"
+	group.Editable = !coventryCustom.Section("groups").HasKey(id)
 	if display.HasKey(id) {
 		key, err := display.GetKey(id)
 		if err == nil {
@@ -390,6 +416,7 @@ func fetchPolicy(id string) *Group {
 	}
 
 	group.Type = "access"
+	group.Editable = !coventryCustom.Section("access").HasKey(id)
 	key, err := access.GetKey(id)
 	if err != nil {
 		return group
@@ -454,17 +481,20 @@ func UpdateLine(extension int, line *Line) error {
 	}
 
 	id := strconv.Itoa(extension)
-	coventryUpdate.DeleteSection(id)
-	err := updateKeys(coventryUpdate, id, line)
-	if err == nil {
-		err = coventryUpdate.SaveTo(coventrySaveTo)
+	contactLock.Lock()
+	defer contactLock.Unlock()
+	lock.Lock()
+	defer lock.Unlock()
+	if contact := speedContact(id); contact != nil && !coventryConfig.HasSection(id) {
+		return fmt.Errorf("line %s is a speed dial for %s", id, contact.Name)
 	}
 
+	coventryUpdate.DeleteSection(id)
+	err := updateKeys(coventryUpdate, id, line)
 	if err != nil {
 		return err
 	}
-
-	return ReloadCoventry()
+	return saveUpdate()
 }
 
 func RemoveLine(extension int) error {
@@ -472,6 +502,8 @@ func RemoveLine(extension int) error {
 		return fmt.Errorf("invalid line number")
 	}
 
+	lock.Lock()
+	defer lock.Unlock()
 	coventryUpdate.DeleteSection(strconv.Itoa(extension))
 
 	err := coventryUpdate.SaveTo(coventrySaveTo)
@@ -546,6 +578,9 @@ func CountLines() int {
 	lines := 0
 	lock.RLock()
 	defer lock.RUnlock()
+	if coventryConfig == nil {
+		return 0
+	}
 
 	for _, section := range coventryConfig.Sections() {
 		key := section.Name()
@@ -556,3 +591,184 @@ func CountLines() int {
 	}
 	return lines
 }
+
+func UpdateRealm(realm, digests string) ([]int, error) {
+	var reset []int
+	algo := parseAlgorithm(digests)
+	if len(algo) < 1 {
+		return nil, fmt.Errorf("invalid digest algorithm")
+	}
+
+	if len(realm) < 1 || len(realm) > 63 || strings.ContainsAny(realm, " \t\"':;@") {
+		return nil, fmt.Errorf("invalid realm")
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	server := coventryUpdate.Section("server")
+	SetConfig(server, "realm", realm)
+	SetConfig(server, "algorithm", algo)
+
+	// realm and algorithm are part of every stored digest
+	for _, section := range coventryConfig.Sections() {
+		key := section.Name()
+		if key < "10" || key > "89" || len(coventryCustom.Section(key).Keys()) > 0 {
+			continue
+		}
+
+		id, err := strconv.Atoi(key)
+		if err != nil {
+			continue
+		}
+
+		if coventryUpdate.HasSection(key) {
+			saved := coventryUpdate.Section(key)
+			saved.DeleteKey("md5")
+			saved.DeleteKey("sha256")
+		}
+
+		if !section.HasKey("secret") {
+			reset = append(reset, id)
+		}
+	}
+
+	err := coventryUpdate.SaveTo(coventrySaveTo)
+	if err != nil {
+		return nil, err
+	}
+
+	Realm = realm
+	Algorithm = algo
+	sort.Ints(reset)
+	return reset, nil
+}
+
+func ParseMembers(members string) ([]int, error) {
+	var out []int
+	list := strings.FieldsFunc(members, func(r rune) bool {
+		return r == ',' || r == ';' || r == ':' || r == ' ' || r == '\t'
+	})
+	for _, str := range list {
+		member, err := strconv.Atoi(str)
+		if err != nil || member < 10 || member > 89 {
+			return nil, fmt.Errorf("invalid member %s", str)
+		}
+		out = append(out, member)
+	}
+	return out, nil
+}
+
+func isGroup(id string) bool {
+	number, err := strconv.Atoi(id)
+	return err == nil && number >= 100 && strconv.Itoa(number) == id
+}
+
+func ExistsGroup(id string) bool {
+	lock.RLock()
+	defer lock.RUnlock()
+	return coventryConfig.Section("groups").HasKey(id)
+}
+
+func NewGroup() (string, *Group) {
+	group := &Group{Type: "group", Editable: true}
+	lock.RLock()
+	defer lock.RUnlock()
+	section := coventryConfig.Section("groups")
+	for number := 100; number <= 999; number++ {
+		id := strconv.Itoa(number)
+		if !section.HasKey(id) {
+			return id, group
+		}
+	}
+	return "", nil
+}
+
+func UpdateGroup(id string, group *Group) error {
+	if !isGroup(id) {
+		return fmt.Errorf("invalid group number")
+	}
+
+	// form values are reused by fiber, so keep copies in the config
+	id = strings.Clone(id)
+	name := strings.Clone(group.Display)
+	var members []string
+	contactLock.Lock()
+	defer contactLock.Unlock()
+	lock.Lock()
+	defer lock.Unlock()
+	if coventryCustom.Section("groups").HasKey(id) {
+		return fmt.Errorf("custom groups not changeable")
+	}
+
+	if usedCode(id) == "feature" {
+		return fmt.Errorf("%s already used by a feature", id)
+	}
+
+	if contact := speedContact(id); contact != nil && !coventryConfig.Section("groups").HasKey(id) {
+		return fmt.Errorf("group %s is a speed dial for %s", id, contact.Name)
+	}
+
+	for _, member := range group.Members {
+		if !coventryConfig.HasSection(strconv.Itoa(member)) {
+			return fmt.Errorf("line %d does not exist", member)
+		}
+		members = append(members, strconv.Itoa(member))
+	}
+
+	if len(members) < 1 {
+		return fmt.Errorf("group has no members")
+	}
+
+	SetConfig(coventryUpdate.Section("groups"), id, strings.Join(members, ","))
+	display := coventryUpdate.Section("display")
+	if len(name) > 0 {
+		SetConfig(display, id, name)
+	} else {
+		display.DeleteKey(id)
+	}
+
+	return saveUpdate()
+}
+
+func RemoveGroup(id string) error {
+	if !isGroup(id) {
+		return fmt.Errorf("invalid group number")
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	if coventryCustom.Section("groups").HasKey(id) {
+		return fmt.Errorf("custom groups not changeable")
+	}
+
+	if !coventryUpdate.Section("groups").HasKey(id) {
+		return fmt.Errorf("group %s does not exist", id)
+	}
+
+	coventryUpdate.Section("groups").DeleteKey(id)
+	coventryUpdate.Section("display").DeleteKey(id)
+	return saveUpdate()
+}
+
+func CountRegistered() int {
+	registered := 0
+	lock.RLock()
+	defer lock.RUnlock()
+	if coventryConfig == nil {
+		return 0
+	}
+
+	for _, section := range coventryConfig.Sections() {
+		key := section.Name()
+		if !isLine(key) {
+			continue
+		}
+		id, _ := strconv.Atoi(key)
+		line := &Line{Agent: "offline"}
+		getRegistry(id, line)
+		if line.Agent != "offline" {
+			registered++
+		}
+	}
+	return registered
+}
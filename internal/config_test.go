@@ -0,0 +1,88 @@
+// Copyright (C) 2023 Tycho Softworks.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package apollo
+
+import (
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/ini.v1"
+)
+
+// empty coventry configs saving to a temp dir, restored after the test
+func emptyConfig(t *testing.T) {
+	t.Helper()
+	config, update, custom, saveTo := coventryConfig, coventryUpdate, coventryCustom, coventrySaveTo
+	coventryConfig = ini.Empty()
+	coventryUpdate = ini.Empty()
+	coventryCustom = ini.Empty()
+	coventrySaveTo = filepath.Join(t.TempDir(), "dynamic.conf")
+	t.Cleanup(func() {
+		coventryConfig, coventryUpdate, coventryCustom, coventrySaveTo = config, update, custom, saveTo
+	})
+}
+
+func TestParseAlgorithm(t *testing.T) {
+	tests := map[string]string{
+		"sha-256":      "SHA-256",
+		"MD5":          "MD5",
+		"md5, sha256":  "SHA-256, MD5",
+		"SHA-256, MD5": "SHA-256, MD5",
+		"none":         "",
+	}
+	for input, expected := range tests {
+		actual := parseAlgorithm(input)
+		if actual != expected {
+			t.Errorf("Expected parseAlgorithm(%q) to return %q, but got %q", input, expected, actual)
+		}
+	}
+}
+
+func TestParseMembers(t *testing.T) {
+	members, err := ParseMembers("10, 11;12")
+	if err != nil || len(members) != 3 || members[2] != 12 {
+		t.Errorf("Expected ParseMembers to return [10 11 12], but got %v, %v", members, err)
+	}
+
+	_, err = ParseMembers("10, 90")
+	if err == nil {
+		t.Errorf("Expected ParseMembers to reject line 90")
+	}
+}
+
+func TestIsGroup(t *testing.T) {
+	if !isGroup("100") || !isGroup("2000") {
+		t.Errorf("Expected isGroup to accept 100 and 2000")
+	}
+	if isGroup("99") || isGroup("0100") || isGroup("abc") {
+		t.Errorf("Expected isGroup to reject 99, 0100, and abc")
+	}
+}
+
+func TestRemoveGroup(t *testing.T) {
+	emptyConfig(t)
+	coventryConfig.Section("groups").Key("100").SetValue("10,11")
+	coventryConfig.Section("groups").Key("200").SetValue("10,11")
+	coventryUpdate.Section("groups").Key("200").SetValue("10,11")
+
+	if err := RemoveGroup("100"); err == nil {
+		t.Errorf("Expected RemoveGroup to reject a group only in coventry.conf")
+	}
+
+	if err := RemoveGroup("200"); err != nil || coventryUpdate.Section("groups").HasKey("200") {
+		t.Errorf("Expected RemoveGroup to remove group 200, but got %v", err)
+	}
+}
@@ -0,0 +1,277 @@
+// Copyright (C) 2023 Tycho Softworks.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package apollo
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// directory entry, global when line is 0
+type Contact struct {
+	Id           int    `json:"id"`
+	Name         string `json:"name"`
+	Number       string `json:"number"`
+	Speed        string `json:"speed,omitempty"`
+	Organization string `json:"organization,omitempty"`
+	Line         int    `json:"line,omitempty"`
+}
+
+var (
+	contactLock  sync.Mutex
+	contactPath  string
+	contactsList = make(map[int]*Contact)
+	contactNext  = 1
+)
+
+func isSpeed(code string) bool {
+	if len(code) < 1 || len(code) > 4 {
+		return false
+	}
+
+	for _, ch := range code {
+		if ch < '0' || ch > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// contact dialed by a speed code, caller holds contactLock
+func speedContact(code string) *Contact {
+	for _, contact := range contactsList {
+		if contact.Speed == code {
+			return contact
+		}
+	}
+	return nil
+}
+
+func isNumber(number string) bool {
+	if len(number) < 1 || len(number) > 64 || strings.ContainsAny(number, " \t,;\"") {
+		return false
+	}
+
+	if strings.Contains(number, "@") {
+		return true
+	}
+
+	for _, ch := range number {
+		if (ch < '0' || ch > '9') && ch != '+' && ch != '*' && ch != '#' {
+			return false
+		}
+	}
+	return true
+}
+
+// load contact store
+func Contacts(path string) error {
+	contactLock.Lock()
+	defer contactLock.Unlock()
+	contactPath = path
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	var contacts []*Contact
+	err = json.Unmarshal(data, &contacts)
+	if err != nil {
+		return err
+	}
+
+	contactsList = make(map[int]*Contact)
+	for _, contact := range contacts {
+		contactsList[contact.Id] = contact
+		if contact.Id >= contactNext {
+			contactNext = contact.Id + 1
+		}
+	}
+	return nil
+}
+
+func saveContacts() error {
+	if len(contactPath) == 0 {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(sortedContacts(func(*Contact) bool { return true }), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := contactPath + ".tmp"
+	err = os.WriteFile(tmp, data, 0640)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, contactPath)
+}
+
+// contacts by name, caller holds contactLock
+func sortedContacts(match func(*Contact) bool) []*Contact {
+	contacts := make([]*Contact, 0)
+	for _, contact := range contactsList {
+		if match(contact) {
+			copy := *contact
+			contacts = append(contacts, &copy)
+		}
+	}
+	sort.Slice(contacts, func(i, j int) bool {
+		if contacts[i].Name != contacts[j].Name {
+			return contacts[i].Name < contacts[j].Name
+		}
+		return contacts[i].Id < contacts[j].Id
+	})
+	return contacts
+}
+
+// every global and personal contact
+func GetContacts() []*Contact {
+	contactLock.Lock()
+	defer contactLock.Unlock()
+	return sortedContacts(func(*Contact) bool { return true })
+}
+
+// global contacts merged with those of a line
+func LineContacts(line int) []*Contact {
+	contactLock.Lock()
+	defer contactLock.Unlock()
+	return sortedContacts(func(contact *Contact) bool {
+		return contact.Line == 0 || contact.Line == line
+	})
+}
+
+// contacts of a line only
+func PersonalContacts(line int) []*Contact {
+	contactLock.Lock()
+	defer contactLock.Unlock()
+	return sortedContacts(func(contact *Contact) bool {
+		return contact.Line == line
+	})
+}
+
+func GetContact(id int) *Contact {
+	contactLock.Lock()
+	defer contactLock.Unlock()
+	contact := contactsList[id]
+	if contact == nil {
+		return nil
+	}
+	copy := *contact
+	return &copy
+}
+
+// speed codes are shared by global contacts and each line's own
+func checkSpeed(contact *Contact) error {
+	if contact.Speed == "" {
+		return nil
+	}
+
+	if !isSpeed(contact.Speed) {
+		return fmt.Errorf("speed dial must be 1 to 4 digits")
+	}
+
+	for _, other := range contactsList {
+		if other.Id == contact.Id || other.Speed != contact.Speed {
+			continue
+		}
+		if other.Line == 0 || contact.Line == 0 || other.Line == contact.Line {
+			return fmt.Errorf("speed dial %s used by %s", contact.Speed, other.Name)
+		}
+	}
+
+	lock.RLock()
+	defer lock.RUnlock()
+	if used := usedCode(contact.Speed); used != "" {
+		return fmt.Errorf("speed dial %s is a %s", contact.Speed, used)
+	}
+	return nil
+}
+
+// save contact, a new one when id is 0
+func UpdateContact(contact *Contact) (int, error) {
+	saved := &Contact{
+		Id:           contact.Id,
+		Name:         strings.Clone(strings.TrimSpace(contact.Name)),
+		Number:       strings.Clone(strings.TrimSpace(contact.Number)),
+		Speed:        strings.Clone(strings.TrimSpace(contact.Speed)),
+		Organization: strings.Clone(strings.TrimSpace(contact.Organization)),
+		Line:         contact.Line,
+	}
+
+	if saved.Name == "" || len(saved.Name) > 64 {
+		return 0, fmt.Errorf("contact name must be 1 to 64 characters")
+	}
+
+	if !isNumber(saved.Number) {
+		return 0, fmt.Errorf("invalid contact number")
+	}
+
+	if len(saved.Organization) > 64 {
+		return 0, fmt.Errorf("organization longer than 64")
+	}
+
+	if saved.Line != 0 && !ExistsLine(saved.Line) {
+		return 0, fmt.Errorf("line %d does not exist", saved.Line)
+	}
+
+	contactLock.Lock()
+	defer contactLock.Unlock()
+	if saved.Id != 0 && contactsList[saved.Id] == nil {
+		return 0, fmt.Errorf("contact %d does not exist", saved.Id)
+	}
+
+	err := checkSpeed(saved)
+	if err != nil {
+		return 0, err
+	}
+
+	if saved.Id == 0 {
+		saved.Id = contactNext
+		contactNext++
+	}
+
+	contactsList[saved.Id] = saved
+	return saved.Id, saveContacts()
+}
+
+func RemoveContact(id int) error {
+	contactLock.Lock()
+	defer contactLock.Unlock()
+	if contactsList[id] == nil {
+		return fmt.Errorf("contact %d does not exist", id)
+	}
+
+	delete(contactsList, id)
+	return saveContacts()
+}
+
+func (contact *Contact) Scope() string {
+	if contact.Line == 0 {
+		return "global"
+	}
+	return strconv.Itoa(contact.Line)
+}
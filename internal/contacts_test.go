@@ -0,0 +1,76 @@
+// Copyright (C) 2023 Tycho Softworks.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package apollo
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestContacts(t *testing.T) {
+	emptyConfig(t)
+	coventryConfig.Section("10")
+	coventryConfig.Section("11")
+	coventryConfig.Section("groups").Key("100").SetValue("10,11")
+	coventryConfig.Section("features").Key("*99").SetValue("echo")
+	coventryConfig.Section("features").Key("55").SetValue("echo")
+
+	if err := Contacts(filepath.Join(t.TempDir(), "contacts.json")); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, speed := range []string{"10", "100", "55", "*99", "12345"} {
+		if _, err := UpdateContact(&Contact{Name: "Bad", Number: "5551212", Speed: speed}); err == nil {
+			t.Errorf("Expected error for speed dial %q", speed)
+		}
+	}
+
+	if _, err := UpdateContact(&Contact{Name: "Office", Number: "+15551212", Speed: "1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := UpdateContact(&Contact{Name: "Home", Number: "5550000", Speed: "1", Line: 10}); err == nil {
+		t.Errorf("Expected error for personal speed dial used by global")
+	}
+
+	if _, err := UpdateContact(&Contact{Name: "Home", Number: "5550000", Speed: "2", Line: 10}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := UpdateContact(&Contact{Name: "Home", Number: "sip:home@example.com", Speed: "2", Line: 11}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(LineContacts(10)) != 2 || len(LineContacts(12)) != 1 || len(GetContacts()) != 3 {
+		t.Errorf("Unexpected merged contacts")
+	}
+
+	if _, err := UpdateContact(&Contact{Name: "Desk", Number: "5551234", Speed: "300"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := UpdateContact(&Contact{Name: "Lab", Number: "5554321", Speed: "12", Line: 11}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := UpdateGroup("300", &Group{Members: []int{10, 11}}); err == nil {
+		t.Errorf("Expected error for group used by speed dial")
+	}
+
+	if err := UpdateLine(12, &Line{}); err == nil {
+		t.Errorf("Expected error for line used by speed dial")
+	}
+}
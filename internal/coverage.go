@@ -0,0 +1,188 @@
+// Copyright (C) 2023 Tycho Softworks.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package apollo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type Coverage struct {
+	Targets []string `json:"targets"`
+	Delayed int      `json:"delayed"`
+}
+
+type RingStep struct {
+	Delay   int      `json:"delay"`
+	Targets []string `json:"targets"`
+	Lines   []int    `json:"lines"`
+}
+
+func isLine(id string) bool {
+	number, err := strconv.Atoi(id)
+	return err == nil && number >= 10 && number <= 89 && strconv.Itoa(number) == id
+}
+
+func splitTargets(targets string) []string {
+	return strings.FieldsFunc(targets, func(r rune) bool {
+		return r == ',' || r == ';' || r == ':' || r == ' ' || r == '\t'
+	})
+}
+
+func defaultDelay() int {
+	delay, err := strconv.Atoi(GetConfig(coventryConfig.Section("calls"), "delayed", "12"))
+	if err != nil {
+		return 12
+	}
+	return delay
+}
+
+func ParseTargets(id, targets string) ([]string, error) {
+	var out []string
+	lock.RLock()
+	defer lock.RUnlock()
+	for _, target := range splitTargets(targets) {
+		if target == id {
+			return nil, fmt.Errorf("%s cannot cover itself", id)
+		}
+
+		if isLine(target) && coventryConfig.HasSection(target) {
+			out = append(out, target)
+		} else if isGroup(target) && coventryConfig.Section("groups").HasKey(target) {
+			out = append(out, target)
+		} else {
+			return nil, fmt.Errorf("invalid coverage target %s", target)
+		}
+	}
+	return out, nil
+}
+
+func ParseDelay(delay string) (int, error) {
+	if len(delay) < 1 {
+		lock.RLock()
+		defer lock.RUnlock()
+		return defaultDelay(), nil
+	}
+
+	seconds, err := strconv.Atoi(delay)
+	if err != nil || seconds < 0 || seconds > 300 {
+		return 0, fmt.Errorf("invalid coverage delay")
+	}
+	return seconds, nil
+}
+
+func GetCoverage(id string) *Coverage {
+	var targets, delayed string
+	lock.RLock()
+	defer lock.RUnlock()
+	if isLine(id) && coventryConfig.HasSection(id) {
+		section := coventryConfig.Section(id)
+		targets = GetConfig(section, "coverage", "")
+		delayed = GetConfig(section, "delayed", "")
+	} else if isGroup(id) && coventryConfig.Section("groups").HasKey(id) {
+		targets = GetConfig(coventryConfig.Section("coverage"), id, "")
+		delayed = GetConfig(coventryConfig.Section("delayed"), id, "")
+	} else {
+		return nil
+	}
+
+	cover := &Coverage{Targets: splitTargets(targets), Delayed: defaultDelay()}
+	seconds, err := strconv.Atoi(delayed)
+	if err == nil {
+		cover.Delayed = seconds
+	}
+	return cover
+}
+
+func UpdateCoverage(id string, cover *Coverage) error {
+	targets := strings.Join(cover.Targets, ",")
+	delayed := strconv.Itoa(cover.Delayed)
+	lock.Lock()
+	defer lock.Unlock()
+	if isLine(id) && coventryConfig.HasSection(id) {
+		if len(coventryCustom.Section(id).Keys()) > 0 {
+			return fmt.Errorf("custom lines not changeable")
+		}
+		section := coventryUpdate.Section(id)
+		if len(targets) > 0 {
+			SetConfig(section, "coverage", targets)
+			SetConfig(section, "delayed", delayed)
+		} else {
+			section.DeleteKey("coverage")
+			section.DeleteKey("delayed")
+		}
+	} else if isGroup(id) && coventryConfig.Section("groups").HasKey(id) {
+		if coventryCustom.Section("groups").HasKey(id) {
+			return fmt.Errorf("custom groups not changeable")
+		}
+		if len(targets) > 0 {
+			SetConfig(coventryUpdate.Section("coverage"), id, targets)
+			SetConfig(coventryUpdate.Section("delayed"), id, delayed)
+		} else {
+			coventryUpdate.Section("coverage").DeleteKey(id)
+			coventryUpdate.Section("delayed").DeleteKey(id)
+		}
+	} else {
+		return fmt.Errorf("invalid line or group")
+	}
+
+	return saveUpdate()
+}
+
+// ring sequence for a call to id, primary first and then coverage
+func RingSequence(id string, cover *Coverage) []RingStep {
+	ringing := make(map[int]bool)
+	lock.RLock()
+	defer lock.RUnlock()
+
+	expand := func(targets []string) []int {
+		var lines []int
+		for _, target := range targets {
+			var members []int
+			if isLine(target) {
+				number, _ := strconv.Atoi(target)
+				members = []int{number}
+			} else if group := fetchGroup(target); group != nil {
+				members = group.Members
+			}
+			for _, member := range members {
+				if !ringing[member] {
+					ringing[member] = true
+					lines = append(lines, member)
+				}
+			}
+		}
+		return lines
+	}
+
+	steps := []RingStep{{Delay: 0, Targets: []string{id}, Lines: expand([]string{id})}}
+	if len(cover.Targets) < 1 {
+		return steps
+	}
+
+	if cover.Delayed == 0 {
+		steps[0].Targets = append(steps[0].Targets, cover.Targets...)
+		steps[0].Lines = append(steps[0].Lines, expand(cover.Targets)...)
+		return steps
+	}
+
+	return append(steps, RingStep{
+		Delay:   cover.Delayed,
+		Targets: cover.Targets,
+		Lines:   expand(cover.Targets),
+	})
+}
@@ -0,0 +1,103 @@
+// Copyright (C) 2023 Tycho Softworks.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package apollo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCoverage(t *testing.T) {
+	emptyConfig(t)
+	coventryConfig.Section("10")
+	coventryConfig.Section("11")
+	coventryConfig.Section("12")
+	coventryConfig.Section("calls").Key("delayed").SetValue("15")
+	coventryConfig.Section("groups").Key("100").SetValue("11,12")
+
+	targets := []struct {
+		id      string
+		targets string
+		want    []string
+		valid   bool
+	}{
+		{"10", "", nil, true},
+		{"10", "11, 100", []string{"11", "100"}, true},
+		{"10", "11;12:100", []string{"11", "12", "100"}, true},
+		{"10", "10", nil, false},
+		{"100", "100", nil, false},
+		{"10", "13", nil, false},
+		{"10", "101", nil, false},
+		{"10", "011", nil, false},
+		{"10", "*99", nil, false},
+	}
+
+	for _, test := range targets {
+		got, err := ParseTargets(test.id, test.targets)
+		if (err == nil) != test.valid || !reflect.DeepEqual(got, test.want) {
+			t.Errorf("ParseTargets(%q, %q) = %v, %v", test.id, test.targets, got, err)
+		}
+	}
+
+	delays := []struct {
+		delay string
+		want  int
+		valid bool
+	}{
+		{"", 15, true},
+		{"0", 0, true},
+		{"30", 30, true},
+		{"300", 300, true},
+		{"301", 0, false},
+		{"-1", 0, false},
+		{"ten", 0, false},
+	}
+
+	for _, test := range delays {
+		got, err := ParseDelay(test.delay)
+		if (err == nil) != test.valid || got != test.want {
+			t.Errorf("ParseDelay(%q) = %d, %v", test.delay, got, err)
+		}
+	}
+
+	sequences := []struct {
+		id    string
+		cover Coverage
+		want  []RingStep
+	}{
+		{"10", Coverage{}, []RingStep{
+			{Delay: 0, Targets: []string{"10"}, Lines: []int{10}},
+		}},
+		{"10", Coverage{Targets: []string{"11"}, Delayed: 0}, []RingStep{
+			{Delay: 0, Targets: []string{"10", "11"}, Lines: []int{10, 11}},
+		}},
+		{"10", Coverage{Targets: []string{"100"}, Delayed: 20}, []RingStep{
+			{Delay: 0, Targets: []string{"10"}, Lines: []int{10}},
+			{Delay: 20, Targets: []string{"100"}, Lines: []int{11, 12}},
+		}},
+		{"100", Coverage{Targets: []string{"12", "10"}, Delayed: 10}, []RingStep{
+			{Delay: 0, Targets: []string{"100"}, Lines: []int{11, 12}},
+			{Delay: 10, Targets: []string{"12", "10"}, Lines: []int{10}},
+		}},
+	}
+
+	for _, test := range sequences {
+		got := RingSequence(test.id, &test.cover)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("RingSequence(%q, %v) = %v", test.id, test.cover, got)
+		}
+	}
+}
@@ -18,6 +18,7 @@ package apollo
 import (
 	"crypto/md5"
 	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/hex"
 	"strings"
 )
@@ -41,3 +42,22 @@ func ComputeSHA256(id string, secret string) string {
 	digest.Write([]byte(id + ":" + Realm + ":" + secret))
 	return hex.EncodeToString(digest.Sum(nil))
 }
+
+func sameSecret(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// check a line password against its own stored digests; lines without
+// them, such as those using the common password, cannot log in.
+func VerifySecret(id string, line *Line, secret string) bool {
+	switch {
+	case len(secret) == 0:
+		return false
+	case len(line.SHA256) > 0:
+		return sameSecret(ComputeSHA256(id, secret), line.SHA256)
+	case len(line.MD5) > 0:
+		return sameSecret(ComputeMD5(id, secret), line.MD5)
+	default:
+		return false
+	}
+}
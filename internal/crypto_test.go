@@ -62,3 +62,30 @@ func TestComputeSHA256(t *testing.T) {
 		t.Errorf("Expected ComputeSHA256 to return %q, but got %q", expected, actual)
 	}
 }
+
+func TestVerifySecret(t *testing.T) {
+	Realm = "test-realm"
+	Password = ""
+	line := &Line{SHA256: ComputeSHA256("10", "pass")}
+	if !VerifySecret("10", line, "pass") || VerifySecret("10", line, "wrong") || VerifySecret("11", line, "pass") {
+		t.Errorf("Expected VerifySecret to match sha256 digest")
+	}
+
+	line = &Line{MD5: ComputeMD5("10", "pass")}
+	if !VerifySecret("10", line, "pass") || VerifySecret("10", line, "") {
+		t.Errorf("Expected VerifySecret to match md5 digest")
+	}
+
+	line = &Line{}
+	if VerifySecret("10", line, "pass") {
+		t.Errorf("Expected VerifySecret to fail without any password")
+	}
+
+	// the shared common password and plain secrets never log in a line
+	Password = "common"
+	defer func() { Password = "" }()
+	line = &Line{Secret: "plain"}
+	if VerifySecret("10", line, "common") || VerifySecret("10", line, "plain") {
+		t.Errorf("Expected VerifySecret to fail without a line digest")
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright (C) 2023 Tycho Softworks.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package apollo
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type Feature struct {
+	Code     string `json:"code"`
+	Action   string `json:"action"`
+	Editable bool   `json:"editable"`
+}
+
+// star codes only, so they can never collide with dialed numbers
+func isFeature(code string) bool {
+	digits, ok := strings.CutPrefix(code, "*")
+	if !ok || len(digits) < 2 || len(digits) > 4 {
+		return false
+	}
+
+	for _, ch := range digits {
+		if ch < '0' || ch > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// what a dialed speed code reaches, including digit only features from
+// custom.conf, caller must lock
+func usedCode(code string) string {
+	if coventryConfig.HasSection(code) && isLine(code) {
+		return "line"
+	}
+
+	if coventryConfig.Section("groups").HasKey(code) {
+		return "group"
+	}
+
+	if coventryConfig.Section("features").HasKey(code) {
+		return "feature"
+	}
+	return ""
+}
+
+// dynamic features replace the defaults, so carry them over first
+func seedFeatures() {
+	if coventryUpdate.HasSection("features") {
+		return
+	}
+
+	custom := coventryCustom.Section("features")
+	section := coventryUpdate.Section("features")
+	for _, key := range coventryConfig.Section("features").Keys() {
+		if !custom.HasKey(key.Name()) {
+			SetConfig(section, key.Name(), key.Value())
+		}
+	}
+}
+
+func GetFeatureCodes() []*Feature {
+	var features []*Feature
+	lock.RLock()
+	defer lock.RUnlock()
+	custom := coventryCustom.Section("features")
+	for _, key := range coventryConfig.Section("features").Keys() {
+		features = append(features, &Feature{
+			Code:     key.Name(),
+			Action:   key.Value(),
+			Editable: !custom.HasKey(key.Name()),
+		})
+	}
+
+	sort.Slice(features, func(i, j int) bool {
+		return features[i].Code < features[j].Code
+	})
+	return features
+}
+
+func UpdateFeature(code, action string) error {
+	code = strings.Clone(code)
+	action = strings.Clone(action)
+	if !isFeature(code) {
+		return fmt.Errorf("invalid feature code")
+	}
+
+	if len(action) < 1 || len(action) > 64 || strings.ContainsAny(action, " \t;,") {
+		return fmt.Errorf("invalid feature action")
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	if coventryCustom.Section("features").HasKey(code) {
+		return fmt.Errorf("custom features not changeable")
+	}
+
+	seedFeatures()
+	SetConfig(coventryUpdate.Section("features"), code, action)
+	return saveUpdate()
+}
+
+func RemoveFeature(code string) error {
+	lock.Lock()
+	defer lock.Unlock()
+	if coventryCustom.Section("features").HasKey(code) {
+		return fmt.Errorf("custom features not changeable")
+	}
+
+	if !coventryConfig.Section("features").HasKey(code) {
+		return fmt.Errorf("feature %s does not exist", code)
+	}
+
+	seedFeatures()
+	coventryUpdate.Section("features").DeleteKey(code)
+	return saveUpdate()
+}
@@ -0,0 +1,35 @@
+// Copyright (C) 2023 Tycho Softworks.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package apollo
+
+import "testing"
+
+func TestFeatureCodes(t *testing.T) {
+	for code, valid := range map[string]bool{
+		"*99":    true,
+		"*1234":  true,
+		"99":     false,
+		"100":    false,
+		"*9":     false,
+		"*12345": false,
+		"*9a":    false,
+		"**99":   false,
+	} {
+		if isFeature(code) != valid {
+			t.Errorf("Feature code %q valid should be %v", code, valid)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+// Copyright (C) 2023 Tycho Softworks.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package apollo
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ipinfo/go/v2/ipinfo"
+	"gitlab.com/tychosoft/service"
+)
+
+type Location struct {
+	Country string `json:"country"`
+	Region  string `json:"region"`
+	City    string `json:"city"`
+}
+
+var (
+	geoLock   sync.Mutex
+	geoPath   string
+	geoCache  = make(map[string]*Location)
+	geoFailed = make(map[string]time.Time)
+	geoLocal  = &Location{Country: "local"}
+)
+
+// failed lookups are retried after this long
+const geoRetry = time.Hour
+
+// address from a sip uri, host:port, or bare ip, empty if not an ip
+func RemoteHost(uri string) string {
+	uri = strings.Trim(uri, "<>")
+	if pos := strings.LastIndex(uri, "@"); pos >= 0 {
+		uri = uri[pos+1:]
+	} else if pos := strings.Index(uri, ":"); pos >= 0 && strings.HasPrefix(uri, "sip") {
+		uri = uri[pos+1:]
+	}
+
+	if pos := strings.IndexAny(uri, ";>"); pos >= 0 {
+		uri = uri[:pos]
+	}
+
+	host, _, err := net.SplitHostPort(uri)
+	if err == nil {
+		uri = host
+	}
+
+	ip := net.ParseIP(strings.Trim(uri, "[]"))
+	if ip == nil {
+		return ""
+	}
+	return ip.String()
+}
+
+func isLocal(ip net.IP) bool {
+	return ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsUnspecified()
+}
+
+// load on-disk geolocation cache
+func Geolocation(path string) error {
+	geoLock.Lock()
+	defer geoLock.Unlock()
+	geoPath = path
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, &geoCache)
+}
+
+func saveGeolocation() error {
+	if len(geoPath) == 0 {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(geoCache, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := geoPath + ".tmp"
+	err = os.WriteFile(tmp, data, 0640)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, geoPath)
+}
+
+// cached location only, never does a lookup
+func CachedLocation(host string) *Location {
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return nil
+	}
+
+	if isLocal(ip) {
+		return geoLocal
+	}
+
+	geoLock.Lock()
+	defer geoLock.Unlock()
+	return geoCache[host]
+}
+
+// locate addresses, looking up each uncached address once; without a
+// token only cached and local addresses are located.
+func Locate(token string, hosts []string) map[string]*Location {
+	located := make(map[string]*Location)
+	var client *ipinfo.Client
+	if len(token) > 0 && token[0] != '*' {
+		client = ipinfo.NewClient(&http.Client{Timeout: 5 * time.Second}, nil, token)
+	}
+
+	// lookups can be slow, so only hold the lock to read and update cache
+	pending := make([]string, 0)
+	queued := make(map[string]bool)
+	geoLock.Lock()
+	for _, host := range hosts {
+		ip := net.ParseIP(host)
+		if ip == nil || located[host] != nil || queued[host] {
+			continue
+		}
+
+		if isLocal(ip) {
+			located[host] = geoLocal
+			continue
+		}
+
+		if location := geoCache[host]; location != nil {
+			located[host] = location
+			continue
+		}
+
+		if client == nil || time.Since(geoFailed[host]) < geoRetry {
+			continue
+		}
+
+		queued[host] = true
+		pending = append(pending, host)
+	}
+	geoLock.Unlock()
+
+	if len(pending) == 0 {
+		return located
+	}
+
+	found := make(map[string]*Location)
+	for _, host := range pending {
+		info, err := client.GetIPInfo(net.ParseIP(host))
+		if err != nil {
+			service.Warn("cannot locate ", host, ": ", err)
+			found[host] = nil
+			continue
+		}
+
+		location := &Location{Country: strings.ToLower(info.Country), Region: info.Region, City: info.City}
+		if info.Bogon {
+			location = geoLocal
+		}
+		found[host] = location
+	}
+
+	geoLock.Lock()
+	defer geoLock.Unlock()
+	for host, failed := range geoFailed {
+		if time.Since(failed) >= geoRetry {
+			delete(geoFailed, host)
+		}
+	}
+
+	changed := false
+	for host, location := range found {
+		if location == nil {
+			geoFailed[host] = time.Now()
+			continue
+		}
+
+		delete(geoFailed, host)
+		geoCache[host] = location
+		located[host] = location
+		changed = true
+	}
+
+	if changed {
+		err := saveGeolocation()
+		if err != nil {
+			service.Error(err)
+		}
+	}
+	return located
+}
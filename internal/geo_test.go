@@ -0,0 +1,65 @@
+// Copyright (C) 2023 Tycho Softworks.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package apollo
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRemoteHost(t *testing.T) {
+	tests := map[string]string{
+		"sip:5551212@8.8.4.4:5060":        "8.8.4.4",
+		"<sip:10@192.168.1.20;transport>": "192.168.1.20",
+		"8.8.8.8":                         "8.8.8.8",
+		"8.8.8.8:5060":                    "8.8.8.8",
+		"sip:[2001:db8::1]:5060":          "2001:db8::1",
+		"sip:10@pbx.example.com":          "",
+	}
+	for input, expected := range tests {
+		actual := RemoteHost(input)
+		if actual != expected {
+			t.Errorf("Expected RemoteHost(%q) to return %q, but got %q", input, expected, actual)
+		}
+	}
+}
+
+func TestLocateWithoutToken(t *testing.T) {
+	located := Locate("", []string{"192.168.1.20", "8.8.8.8", "bogus"})
+	if located["192.168.1.20"] != geoLocal {
+		t.Errorf("Expected private address to be local")
+	}
+	if located["8.8.8.8"] != nil || len(located) != 1 {
+		t.Errorf("Expected no lookups without a token, got %v", located)
+	}
+}
+
+func TestLocateRecentlyFailed(t *testing.T) {
+	geoLock.Lock()
+	geoFailed["8.8.4.4"] = time.Now()
+	geoLock.Unlock()
+	defer func() {
+		geoLock.Lock()
+		delete(geoFailed, "8.8.4.4")
+		geoLock.Unlock()
+	}()
+
+	start := time.Now()
+	located := Locate("token", []string{"8.8.4.4"})
+	if len(located) != 0 || time.Since(start) > time.Second {
+		t.Errorf("Expected recently failed address to be skipped, got %v", located)
+	}
+}
@@ -27,6 +27,8 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"strconv"
+	"time"
 	"unsafe"
 
 	"gitlab.com/tychosoft/service"
@@ -52,12 +54,96 @@ type IpcInfo struct {
 	SessionCount uintptr `json:"session_count,omitempty"`
 }
 
+// highest logging level accepted for any service
+const MaxLevel = 9
+
+// message size limits from pbx_msg
+const (
+	MessageTo   = 32
+	MessageSubj = 64
+	MessageText = 160
+)
+
+type System struct {
+	Started time.Time `json:"started"`
+	Pid     int       `json:"pid"`
+	Series  uint32    `json:"series"`
+	Version uint8     `json:"version"`
+	Level   uint8     `json:"level"`
+	State   string    `json:"state"`
+	Realm   string    `json:"realm"`
+	Digest  string    `json:"digest"`
+}
+
+type MediaSystem struct {
+	Started  time.Time `json:"started"`
+	Series   uint32    `json:"series"`
+	Level    uint32    `json:"level"`
+	Limit    uint32    `json:"limit"`
+	Used     uint32    `json:"used"`
+	Identity string    `json:"identity"`
+	Active   bool      `json:"active"`
+}
+
+type Session struct {
+	Created time.Time `json:"created"`
+	Status  string    `json:"status"`
+	Sid     string    `json:"sid"`
+	Caller  string    `json:"caller"`
+	Dialed  string    `json:"dialed"`
+	Lines   uint32    `json:"lines"`
+	Steps   uint32    `json:"steps"`
+	Audio   uint32    `json:"audio"`
+	Video   uint32    `json:"video"`
+	State   string    `json:"state"`
+	Type    string    `json:"type"`
+}
+
+type Registry struct {
+	Id       int    `json:"id"`
+	Count    uint16 `json:"count"`
+	Presence string `json:"status"`
+	Agent    string `json:"agent"`
+	Expires  int64  `json:"expires"`
+}
+
+type TrunkStatus struct {
+	Registered bool      `json:"registered"`
+	Count      uint16    `json:"count"`
+	Expires    time.Time `json:"expires"`
+	Agent      string    `json:"agent"`
+	Host       string    `json:"host"`
+	Id         string    `json:"id"`
+}
+
+type Call struct {
+	Id      uint64    `json:"id"`
+	Created time.Time `json:"created"`
+	Active  uint16    `json:"active"`
+	Ringing uint16    `json:"ringing"`
+	Caller  string    `json:"caller"`
+	Dialed  string    `json:"dialed"`
+	Remote  string    `json:"remote"`
+	State   string    `json:"state"`
+	Type    string    `json:"type"`
+}
+
 var (
 	ipcCoventry string
 	udpCoventry string
 	ipcRegistry uintptr
-	registryMap *C.pbx_reg_t = nil
-	instance                 = 0
+	ipcCalls    uintptr
+	callCount   int
+	regCount    int
+	registryMap *C.pbx_reg_t  = nil
+	callsMap    *C.pbx_call_t = nil
+	instance                  = 0
+
+	ipcBordeaux  string
+	udpBordeaux  string
+	ipcMedia     uintptr
+	sessionCount int
+	mediaMap     *C.ipc_system_t = nil
 )
 
 func VerifyToken(token string) int {
@@ -75,7 +161,55 @@ func ipcInstance() int {
 }
 
 func ReloadCoventry() error {
-	ptr := C.reload_coventry()
+	return sendCoventry(C.reload_coventry())
+}
+
+func LevelCoventry(level int) error {
+	if level < 0 || level > MaxLevel {
+		return fmt.Errorf("logging level must be 0 to %d", MaxLevel)
+	}
+	return sendCoventry(C.level_coventry(C.int(level)))
+}
+
+func SetPresence(extension int, presence string) error {
+	if extension < 10 || extension > 89 {
+		return fmt.Errorf("invalid line number")
+	}
+
+	switch presence {
+	case "gone", "here", "away", "dnd":
+	default:
+		return fmt.Errorf("invalid presence %s", presence)
+	}
+
+	cs_presence := C.CString(presence)
+	defer C.free(unsafe.Pointer(cs_presence))
+	return sendCoventry(C.presence_coventry(C.int(extension), cs_presence))
+}
+
+func SendMessage(to, subj, text string) error {
+	if len(to) < 1 || len(to) > MessageTo {
+		return fmt.Errorf("message destination must be 1 to %d characters", MessageTo)
+	}
+
+	if len(subj) > MessageSubj {
+		return fmt.Errorf("message subject exceeds %d characters", MessageSubj)
+	}
+
+	if len(text) < 1 || len(text) > MessageText {
+		return fmt.Errorf("message text must be 1 to %d characters", MessageText)
+	}
+
+	cs_to := C.CString(to)
+	cs_subj := C.CString(subj)
+	cs_text := C.CString(text)
+	defer C.free(unsafe.Pointer(cs_to))
+	defer C.free(unsafe.Pointer(cs_subj))
+	defer C.free(unsafe.Pointer(cs_text))
+	return sendCoventry(C.message_coventry(cs_to, cs_subj, cs_text))
+}
+
+func sendCoventry(ptr *C.pbx_msg_t) error {
 	if ptr == nil {
 		return fmt.Errorf("failed to create msg")
 	}
@@ -118,6 +252,9 @@ func ipcInit(coventry string) {
 	udpCoventry = ipc.UDPPath
 	ipcCoventry = ipc.IPCPath
 	ipcRegistry = (ipc.RegSize * ipc.RegCount) + ipc.SysSize
+	callCount = int(ipc.CallCount)
+	regCount = int(ipc.RegCount)
+	callsInit(ipc.CallSize * ipc.CallCount)
 
 	if registryMap != nil {
 		C.munmap(unsafe.Pointer(registryMap), C.size_t(ipcRegistry))
@@ -140,6 +277,136 @@ func ipcInit(coventry string) {
 	}
 }
 
+func callsInit(size uintptr) {
+	if callsMap != nil {
+		C.munmap(unsafe.Pointer(callsMap), C.size_t(ipcCalls))
+		callsMap = nil
+	}
+
+	ipcCalls = size
+	if ipcCalls == 0 {
+		return
+	}
+
+	calls_path := C.CString(ipcCoventry + ".calls")
+	shm := C.shm_open(calls_path, C.O_RDONLY, 0660)
+	defer C.free(unsafe.Pointer(calls_path))
+	if shm < C.int(0) {
+		service.Warn("shared calls missing")
+		return
+	}
+
+	callsMap = C.calls_map(C.size_t(ipcCalls), shm)
+	C.close(shm)
+	if unsafe.Pointer(callsMap) == C.MAP_FAILED || callsMap == nil {
+		callsMap = nil
+		service.Warn("shared calls broken")
+	}
+}
+
+func GetSystem() *System {
+	lock.RLock()
+	defer lock.RUnlock()
+	if registryMap == nil {
+		return nil
+	}
+
+	sys := *C.registry_sys(registryMap, C.size_t(regCount))
+	digest := C.GoString(&sys.digest[0])
+	if algo := parseAlgorithm(digest); len(algo) > 0 {
+		digest = algo
+	}
+
+	return &System{
+		Started: time.Unix(int64(sys.started), 0),
+		Pid:     int(sys.pid),
+		Series:  uint32(sys.series),
+		Version: uint8(sys.version),
+		Level:   uint8(sys.level),
+		State:   C.GoString(&sys.state[0]),
+		Realm:   C.GoString(&sys.realm[0]),
+		Digest:  digest,
+	}
+}
+
+func GetTrunkStatus() *TrunkStatus {
+	lock.RLock()
+	defer lock.RUnlock()
+	if registryMap == nil {
+		return nil
+	}
+
+	entry := C.registry_trunk(registryMap, C.size_t(regCount))
+	cs_host := C.entry_host(entry)
+	defer C.free(unsafe.Pointer(cs_host))
+	status := &TrunkStatus{
+		Count:   uint16(C.entry_count(entry)),
+		Expires: time.Unix(int64(entry.expires), 0),
+		Agent:   C.GoString(&entry.agent[0]),
+		Host:    C.GoString(cs_host),
+		Id:      C.GoString(&entry.id[0]),
+	}
+	status.Registered = entry.expires != 0 && time.Now().Before(status.Expires)
+	return status
+}
+
+func GetCalls() []*Call {
+	calls := make([]*Call, 0)
+	lock.RLock()
+	defer lock.RUnlock()
+	if callsMap == nil {
+		return calls
+	}
+
+	for index := 0; index < callCount; index++ {
+		entry := *C.call_entry(C.int(index), callsMap)
+		if entry.id == 0 {
+			continue
+		}
+
+		calls = append(calls, &Call{
+			Id:      uint64(entry.id),
+			Created: time.Unix(int64(entry.created), 0),
+			Active:  uint16(entry.active),
+			Ringing: uint16(entry.ringing),
+			Caller:  C.GoString(&entry.caller[0]),
+			Dialed:  C.GoString(&entry.dialed[0]),
+			Remote:  C.GoString(&entry.remote[0]),
+			State:   C.GoString(C.call_state(&entry)),
+			Type:    C.GoString(C.call_type(&entry)),
+		})
+	}
+	return calls
+}
+
+// current registry of every configured line
+func GetRegistry() map[int]*Registry {
+	entries := make(map[int]*Registry)
+	lock.RLock()
+	defer lock.RUnlock()
+	if registryMap == nil || coventryConfig == nil {
+		return entries
+	}
+
+	for _, section := range coventryConfig.Sections() {
+		key := section.Name()
+		if !isLine(key) {
+			continue
+		}
+		id, _ := strconv.Atoi(key)
+		line := &Line{Agent: "offline", Presence: "down"}
+		getRegistry(id, line)
+		entries[id] = &Registry{
+			Id:       id,
+			Count:    line.Count,
+			Presence: line.Presence,
+			Agent:    line.Agent,
+			Expires:  int64(C.registry_expires(C.int(id), registryMap)),
+		}
+	}
+	return entries
+}
+
 func getRegistry(id int, line *Line) {
 	if registryMap == nil || id < 10 || id > 89 {
 		return
@@ -161,3 +428,145 @@ func getRegistry(id int, line *Line) {
 
 	agentInfo(line)
 }
+
+// bordeaux is optional, so a missing media server is not fatal
+func Media(prefix string) {
+	var ipc IpcInfo
+	lock.Lock()
+	defer lock.Unlock()
+	data, err := os.ReadFile(prefix + "/ipc.json")
+	if err != nil {
+		service.Warn("media server missing")
+		return
+	}
+	err = json.Unmarshal(data, &ipc)
+	if err != nil {
+		service.Error(err)
+		return
+	}
+
+	if ipc.EventSize != unsafe.Sizeof(C.ipc_event_t{}) ||
+		ipc.SystemSize != unsafe.Sizeof(C.ipc_system_t{}) ||
+		ipc.SessionSize != unsafe.Sizeof(C.ipc_session_t{}) {
+		service.Error(fmt.Errorf("media IPC size mismatch"))
+		return
+	}
+
+	udpBordeaux = ipc.UDPPath
+	ipcBordeaux = ipc.IPCPath
+	if mediaMap != nil {
+		C.munmap(unsafe.Pointer(mediaMap), C.size_t(ipcMedia))
+		mediaMap = nil
+	}
+
+	ipcMedia = ipc.SystemSize + (ipc.SessionSize * ipc.SessionCount)
+	sessionCount = int(ipc.SessionCount)
+	media_path := C.CString(ipcBordeaux + ".sessions")
+	shm := C.shm_open(media_path, C.O_RDONLY, 0660)
+	defer C.free(unsafe.Pointer(media_path))
+	if shm < C.int(0) {
+		service.Warn("shared media missing")
+		return
+	}
+
+	mediaMap = C.media_map(C.size_t(ipcMedia), shm)
+	C.close(shm)
+	if unsafe.Pointer(mediaMap) == C.MAP_FAILED || mediaMap == nil {
+		mediaMap = nil
+		service.Warn("shared media broken")
+	}
+}
+
+func MediaLevel() (int, bool) {
+	lock.RLock()
+	defer lock.RUnlock()
+	if mediaMap == nil {
+		return 0, false
+	}
+	return int(mediaMap.level), true
+}
+
+func GetMedia() *MediaSystem {
+	lock.RLock()
+	defer lock.RUnlock()
+	if mediaMap == nil {
+		return nil
+	}
+
+	return &MediaSystem{
+		Started:  time.Unix(int64(mediaMap.started), 0),
+		Series:   uint32(mediaMap.series),
+		Level:    uint32(mediaMap.level),
+		Limit:    uint32(mediaMap.limit),
+		Used:     uint32(C.media_used(mediaMap)),
+		Identity: C.GoString(&mediaMap.identity[0]),
+		Active:   bool(mediaMap.active),
+	}
+}
+
+func GetSessions() []*Session {
+	sessions := make([]*Session, 0)
+	lock.RLock()
+	defer lock.RUnlock()
+	if mediaMap == nil {
+		return sessions
+	}
+
+	for index := 0; index < sessionCount; index++ {
+		entry := *C.media_session(C.int(index), mediaMap)
+		if entry.created == 0 {
+			continue
+		}
+
+		sessions = append(sessions, &Session{
+			Created: time.Unix(int64(entry.created), 0),
+			Status:  C.GoString(&entry.status[0]),
+			Sid:     C.GoString(&entry.sid[0]),
+			Caller:  C.GoString(&entry.caller[0]),
+			Dialed:  C.GoString(&entry.dialed[0]),
+			Lines:   uint32(entry.lines),
+			Steps:   uint32(entry.steps),
+			Audio:   uint32(entry.audio),
+			Video:   uint32(entry.video),
+			State:   C.GoString(C.session_state(&entry)),
+			Type:    C.GoString(C.session_type(&entry)),
+		})
+	}
+	return sessions
+}
+
+func ReloadBordeaux() error {
+	return sendBordeaux(C.reload_bordeaux())
+}
+
+func LevelBordeaux(level int) error {
+	if level < 0 || level > MaxLevel {
+		return fmt.Errorf("logging level must be 0 to %d", MaxLevel)
+	}
+	return sendBordeaux(C.level_bordeaux(C.int(level)))
+}
+
+func sendBordeaux(ptr *C.ipc_event_t) error {
+	if ptr == nil {
+		return fmt.Errorf("failed to create event")
+	}
+	defer C.free(unsafe.Pointer(ptr))
+
+	if len(udpBordeaux) < 1 {
+		return fmt.Errorf("media server missing")
+	}
+
+	buf := new(bytes.Buffer)
+	err := binary.Write(buf, binary.LittleEndian, (*C.ipc_event_t)(unsafe.Pointer(ptr)))
+	if err != nil {
+		return fmt.Errorf("binary write error: %v", err)
+	}
+	conn, err := net.Dial("unixgram", udpBordeaux)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.Write(buf.Bytes())
+	return err
+}
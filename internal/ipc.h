@@ -51,13 +51,17 @@ char *registry_presence(int id, pbx_reg_t *map) {
     }
 }
 
+long long registry_expires(int id, pbx_reg_t *map) {
+    pbx_reg_t *entry = &map[id - 10];
+    return (long long)entry->expires;
+}
+
 int registry_count(int id, pbx_reg_t *map) {
     pbx_reg_t *entry = &map[id - 10];
     return atomic_load(&entry->count);
 }
 
-char *registry_host(int id, pbx_reg_t *map) {
-    pbx_reg_t *entry = &map[id - 10];
+char *entry_host(pbx_reg_t *entry) {
     char host[128];
     memset(host, 0, sizeof(host));
     struct sockaddr *addr = (struct sockaddr *)&entry->address;
@@ -74,6 +78,19 @@ char *registry_host(int id, pbx_reg_t *map) {
     return strdup(host);
 }
 
+char *registry_host(int id, pbx_reg_t *map) {
+    return entry_host(&map[id - 10]);
+}
+
+uint32_t entry_count(pbx_reg_t *entry) {
+    return atomic_load(&entry->count);
+}
+
+// trunk registration is kept in the sys block
+pbx_reg_t *registry_trunk(pbx_reg_t *map, size_t count) {
+    return &registry_sys(map, count)->trunk;
+}
+
 // verify user token in mapped registry, form id:...
 int verify_user(pbx_reg_t *map, const char *token) {
     int uid = ((token[0] - '0') * 10) + (token[1] - '0');
@@ -87,16 +104,148 @@ int verify_user(pbx_reg_t *map, const char *token) {
     return uid;
 }
 
+// create coventry request
+pbx_msg_t *coventry_msg(pbx_type_t type) {
+    pbx_msg_t *msg = (pbx_msg_t*)calloc(1, sizeof(pbx_msg_t));
+    if (msg != NULL) {
+        msg->type = type;
+        msg->ver = PBX_VERSION;
+    }
+    return msg;
+}
+
 // reload coventry
 pbx_msg_t *reload_coventry() {
-    pbx_msg_t *msg = (pbx_msg_t*)malloc(sizeof(pbx_msg_t));
+    return coventry_msg(PBX_RELOAD);
+}
+
+// text message to extension
+pbx_msg_t *message_coventry(const char *to, const char *subj, const char *text) {
+    pbx_msg_t *msg = coventry_msg(PBX_MESSAGE);
     if (msg != NULL) {
-        msg->type = PBX_RELOAD;
-        msg->ver = PBX_VERSION;
+        strncpy(msg->body.message.to, to, sizeof(msg->body.message.to) - 1);
+        strncpy(msg->body.message.subj, subj, sizeof(msg->body.message.subj) - 1);
+        strncpy(msg->body.message.text, text, sizeof(msg->body.message.text) - 1);
+    }
+    return msg;
+}
+
+// set coventry logging level
+pbx_msg_t *level_coventry(int level) {
+    pbx_msg_t *msg = coventry_msg(PBX_LEVEL);
+    if (msg != NULL)
+        msg->body.level = (uint8_t)level;
+    return msg;
+}
+
+// set presence of extension
+pbx_msg_t *presence_coventry(int ext, const char *presence) {
+    pbx_msg_t *msg = coventry_msg(PBX_PRESENCE);
+    if (msg != NULL) {
+        msg->body.registry.ext = (uint32_t)ext;
+        strncpy(msg->body.registry.key, "presence", sizeof(msg->body.registry.key) - 1);
+        strncpy(msg->body.registry.value, presence, sizeof(msg->body.registry.value) - 1);
     }
     return msg;
 }
 
+// create bordeaux event
+ipc_event_t *bordeaux_event(ipc_type_t type) {
+    ipc_event_t *event = (ipc_event_t*)calloc(1, sizeof(ipc_event_t));
+    if (event != NULL) {
+        event->type = type;
+        event->ver = IPC_VERSION;
+    }
+    return event;
+}
+
+// set bordeaux logging level
+ipc_event_t *level_bordeaux(int level) {
+    ipc_event_t *event = bordeaux_event(IPC_LEVEL);
+    if (event != NULL)
+        event->body.level = (uint32_t)level;
+    return event;
+}
+
+// reload bordeaux
+ipc_event_t *reload_bordeaux() {
+    return bordeaux_event(IPC_RELOAD);
+}
+
+// media sessions follow the system block
+ipc_system_t *media_map(size_t size, int shm) {
+    return mmap(NULL, size, PROT_READ, MAP_SHARED, shm, 0);
+}
+
+uint32_t media_used(ipc_system_t *map) {
+    return atomic_load(&map->used);
+}
+
+ipc_session_t *media_session(int index, ipc_system_t *map) {
+    ipc_session_t *sessions = (ipc_session_t *)((char *)map + sizeof(ipc_system_t));
+    return &sessions[index];
+}
+
+char *session_state(ipc_session_t *session) {
+    switch(session->state) {
+    case ISC_INVITING:
+        return "inviting";
+    case ISC_RINGING:
+        return "ringing";
+    case ISC_CONNECTED:
+        return "connected";
+    case ISC_CLOSING:
+        return "closing";
+    default:
+        return "unknown";
+    }
+}
+
+char *session_type(ipc_session_t *session) {
+    switch(session->type) {
+    case ISC_INBOUND:
+        return "inbound";
+    case ISC_OUTBOUND:
+        return "outbound";
+    default:
+        return "any";
+    }
+}
+
 pbx_reg_t *registry_map(size_t size, int shm) {
     return mmap(NULL, size, PROT_READ, MAP_SHARED, shm, 0);
 }
+
+pbx_call_t *calls_map(size_t size, int shm) {
+    return mmap(NULL, size, PROT_READ, MAP_SHARED, shm, 0);
+}
+
+pbx_call_t *call_entry(int index, pbx_call_t *map) {
+    return &map[index];
+}
+
+char *call_state(pbx_call_t *call) {
+    switch(call->state) {
+    case INVITING:
+        return "inviting";
+    case RINGING:
+        return "ringing";
+    case CONNECTED:
+        return "connected";
+    default:
+        return "closing";
+    }
+}
+
+char *call_type(pbx_call_t *call) {
+    switch(call->type) {
+    case LOCAL:
+        return "local";
+    case INBOUND:
+        return "inbound";
+    case OUTGOING:
+        return "outgoing";
+    default:
+        return "none";
+    }
+}
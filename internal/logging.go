@@ -0,0 +1,40 @@
+// Copyright (C) 2023 Tycho Softworks.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package apollo
+
+import (
+	"sync/atomic"
+
+	"gitlab.com/tychosoft/service"
+)
+
+// reopening the service logger removes the log, so apollo keeps its own
+// debug level that can change while running.
+var verbose atomic.Int32
+
+func SetVerbose(level int) {
+	verbose.Store(int32(level))
+}
+
+func Verbose() int {
+	return int(verbose.Load())
+}
+
+func Debug(level int, args ...any) {
+	if level <= Verbose() {
+		service.Debug(0, args...)
+	}
+}
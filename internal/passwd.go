@@ -0,0 +1,122 @@
+// Copyright (C) 2023 Tycho Softworks.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package apollo
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// argon2id cost of new password hashes
+const (
+	hashTime    = 3
+	hashMemory  = 64 * 1024
+	hashThreads = 2
+	hashSalt    = 16
+	hashLength  = 32
+)
+
+type passwordHash struct {
+	time    uint32
+	memory  uint32
+	threads uint8
+	salt    []byte
+	key     []byte
+}
+
+// hash a password in phc string format, $argon2id$v=19$m=,t=,p=$salt$key
+func HashPassword(pass string) (string, error) {
+	salt := make([]byte, hashSalt)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(pass), salt, hashTime, hashMemory, hashThreads, hashLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, hashMemory, hashTime, hashThreads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func parseHash(stored string) (*passwordHash, error) {
+	parts := strings.Split(stored, "$")
+	if len(parts) != 6 || parts[0] != "" || parts[1] != "argon2id" {
+		return nil, fmt.Errorf("unsupported password hash")
+	}
+
+	var version int
+	_, err := fmt.Sscanf(parts[2], "v=%d", &version)
+	if err != nil || version != argon2.Version {
+		return nil, fmt.Errorf("unsupported argon2 version")
+	}
+
+	hash := &passwordHash{}
+	_, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &hash.memory, &hash.time, &hash.threads)
+	if err != nil || hash.time < 1 || hash.threads < 1 {
+		return nil, fmt.Errorf("invalid argon2 parameters")
+	}
+
+	hash.salt, err = base64.RawStdEncoding.DecodeString(parts[4])
+	if err == nil {
+		hash.key, err = base64.RawStdEncoding.DecodeString(parts[5])
+	}
+	if err != nil || len(hash.key) < 1 {
+		return nil, fmt.Errorf("invalid argon2 hash")
+	}
+	return hash, nil
+}
+
+// legacy web admin hash, an unsalted sha256 of pass:user in hex
+func isLegacyHash(stored string) bool {
+	if len(stored) != sha256.Size*2 {
+		return false
+	}
+
+	_, err := hex.DecodeString(stored)
+	return err == nil
+}
+
+func legacyHash(user, pass string) string {
+	digest := sha256.New()
+	digest.Write([]byte(pass + ":" + user))
+	return hex.EncodeToString(digest.Sum(nil))
+}
+
+// check a password against a stored hash, and if it should be re-hashed
+func CheckPassword(stored, user, pass string) (bool, bool) {
+	if isLegacyHash(stored) {
+		return sameSecret(legacyHash(user, pass), stored), true
+	}
+
+	hash, err := parseHash(stored)
+	if err != nil {
+		return false, false
+	}
+
+	key := argon2.IDKey([]byte(pass), hash.salt, hash.time, hash.memory, hash.threads, uint32(len(hash.key)))
+	if subtle.ConstantTimeCompare(key, hash.key) != 1 {
+		return false, false
+	}
+
+	upgrade := hash.time != hashTime || hash.memory != hashMemory || hash.threads != hashThreads
+	return true, upgrade
+}
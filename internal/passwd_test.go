@@ -0,0 +1,78 @@
+// Copyright (C) 2023 Tycho Softworks.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package apollo
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/argon2"
+)
+
+func TestHashPassword(t *testing.T) {
+	hash, err := HashPassword("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=65536,t=3,p=2$") {
+		t.Errorf("Unexpected hash format %s", hash)
+	}
+
+	other, _ := HashPassword("secret")
+	if other == hash {
+		t.Errorf("Expected different salts")
+	}
+
+	if ok, upgrade := CheckPassword(hash, "admin", "secret"); !ok || upgrade {
+		t.Errorf("Expected current hash to verify, got %v %v", ok, upgrade)
+	}
+
+	if ok, _ := CheckPassword(hash, "admin", "wrong"); ok {
+		t.Errorf("Expected wrong password to fail")
+	}
+
+	// tampered cost parameters derive a different key
+	if ok, _ := CheckPassword(strings.Replace(hash, "t=3", "t=1", 1), "admin", "secret"); ok {
+		t.Errorf("Expected changed parameters to fail")
+	}
+
+	// weaker cost parameters still verify but are upgraded
+	salt := []byte("0123456789abcdef")
+	key := argon2.IDKey([]byte("secret"), salt, 1, 8*1024, 1, hashLength)
+	weaker := "$argon2id$v=19$m=8192,t=1,p=1$" + base64.RawStdEncoding.EncodeToString(salt) + "$" + base64.RawStdEncoding.EncodeToString(key)
+	if ok, upgrade := CheckPassword(weaker, "admin", "secret"); !ok || !upgrade {
+		t.Errorf("Expected weaker hash to verify and upgrade, got %v %v", ok, upgrade)
+	}
+}
+
+func TestLegacyPassword(t *testing.T) {
+	stored := legacyHash("admin", "secret")
+	if ok, upgrade := CheckPassword(stored, "admin", "secret"); !ok || !upgrade {
+		t.Errorf("Expected legacy hash to verify and upgrade, got %v %v", ok, upgrade)
+	}
+
+	if ok, _ := CheckPassword(stored, "root", "secret"); ok {
+		t.Errorf("Expected legacy hash bound to username")
+	}
+
+	for _, bad := range []string{"", "XXX", "$argon2i$v=19$m=65536,t=3,p=2$c2FsdA$a2V5", "$argon2id$v=16$m=65536,t=3,p=2$c2FsdA$a2V5", "$argon2id$v=19$m=65536,t=0,p=2$c2FsdA$a2V5"} {
+		if ok, _ := CheckPassword(bad, "admin", "secret"); ok {
+			t.Errorf("Expected %q to fail", bad)
+		}
+	}
+}
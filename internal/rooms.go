@@ -0,0 +1,240 @@
+// Copyright (C) 2023 Tycho Softworks.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package apollo
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+type Room struct {
+	Name     string `json:"name"`
+	Zone     string `json:"zone"`
+	Lines    []int  `json:"lines"`
+	Editable bool   `json:"editable"`
+}
+
+type Zone struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Rooms       []string `json:"rooms"`
+	Editable    bool     `json:"editable"`
+}
+
+// rooms of each line, caller holds lock
+func lineRooms() map[int]string {
+	rooms := make(map[int]string)
+	common := GetConfig(coventryConfig.Section("common"), "room", "any")
+	for _, section := range coventryConfig.Sections() {
+		key := section.Name()
+		if !isLine(key) {
+			continue
+		}
+		id, _ := strconv.Atoi(key)
+		rooms[id] = GetConfig(section, "room", common)
+	}
+	return rooms
+}
+
+func GetRoomList() []*Room {
+	lock.RLock()
+	defer lock.RUnlock()
+	members := make(map[string][]int)
+	for id, room := range lineRooms() {
+		members[room] = append(members[room], id)
+	}
+
+	var rooms []*Room
+	for _, key := range coventryConfig.Section("rooms").Keys() {
+		room := &Room{
+			Name:     key.Name(),
+			Zone:     key.String(),
+			Lines:    members[key.Name()],
+			Editable: !coventryCustom.Section("rooms").HasKey(key.Name()),
+		}
+		sort.Ints(room.Lines)
+		rooms = append(rooms, room)
+	}
+
+	sort.Slice(rooms, func(i, j int) bool {
+		return rooms[i].Name < rooms[j].Name
+	})
+	return rooms
+}
+
+func GetZoneList() []*Zone {
+	lock.RLock()
+	defer lock.RUnlock()
+	members := make(map[string][]string)
+	for _, key := range coventryConfig.Section("rooms").Keys() {
+		members[key.String()] = append(members[key.String()], key.Name())
+	}
+
+	var zones []*Zone
+	for _, key := range coventryConfig.Section("zones").Keys() {
+		zone := &Zone{
+			Name:        key.Name(),
+			Description: key.String(),
+			Rooms:       members[key.Name()],
+			Editable:    !coventryCustom.Section("zones").HasKey(key.Name()),
+		}
+		sort.Strings(zone.Rooms)
+		zones = append(zones, zone)
+	}
+
+	sort.Slice(zones, func(i, j int) bool {
+		return zones[i].Name < zones[j].Name
+	})
+	return zones
+}
+
+// zone each room is in
+func GetRoomZones() map[string]string {
+	zones := make(map[string]string)
+	lock.RLock()
+	defer lock.RUnlock()
+	for _, key := range coventryConfig.Section("rooms").Keys() {
+		zones[key.Name()] = key.String()
+	}
+	return zones
+}
+
+func UpdateZone(name, description string) error {
+	if !isName(name) {
+		return fmt.Errorf("invalid zone name")
+	}
+
+	if description == "" {
+		description = name
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	if coventryCustom.Section("zones").HasKey(name) {
+		return fmt.Errorf("custom zones not changeable")
+	}
+
+	SetConfig(coventryUpdate.Section("zones"), name, description)
+	return saveUpdate()
+}
+
+func RemoveZone(name string) error {
+	lock.Lock()
+	defer lock.Unlock()
+	if coventryCustom.Section("zones").HasKey(name) {
+		return fmt.Errorf("custom zones not changeable")
+	}
+
+	if !coventryUpdate.Section("zones").HasKey(name) {
+		return fmt.Errorf("zone %s does not exist", name)
+	}
+
+	for _, key := range coventryConfig.Section("rooms").Keys() {
+		if key.String() == name {
+			return fmt.Errorf("zone used by room %s", key.Name())
+		}
+	}
+
+	coventryUpdate.Section("zones").DeleteKey(name)
+	return saveUpdate()
+}
+
+func UpdateRoom(name, zone string) error {
+	if !isName(name) || name == "any" {
+		return fmt.Errorf("invalid room name")
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	if coventryCustom.Section("rooms").HasKey(name) {
+		return fmt.Errorf("custom rooms not changeable")
+	}
+
+	if !coventryConfig.Section("zones").HasKey(zone) {
+		return fmt.Errorf("zone %s does not exist", zone)
+	}
+
+	SetConfig(coventryUpdate.Section("rooms"), name, zone)
+	return saveUpdate()
+}
+
+// rooms with lines or cabled jacks cannot be removed
+func RemoveRoom(name string) error {
+	plantLock.Lock()
+	defer plantLock.Unlock()
+	for _, jack := range plantJacks {
+		if jack.Room == name {
+			return fmt.Errorf("room has jack %s", jack.Id)
+		}
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	if coventryCustom.Section("rooms").HasKey(name) {
+		return fmt.Errorf("custom rooms not changeable")
+	}
+
+	if !coventryUpdate.Section("rooms").HasKey(name) {
+		return fmt.Errorf("room %s does not exist", name)
+	}
+
+	for id, room := range lineRooms() {
+		if room == name {
+			return fmt.Errorf("room used by line %d", id)
+		}
+	}
+
+	coventryUpdate.Section("rooms").DeleteKey(name)
+	return saveUpdate()
+}
+
+// assign line to a room, empty resets to the common room
+func UpdateLineRoom(extension int, room string) error {
+	if extension < 10 || extension > 89 {
+		return fmt.Errorf("invalid line number")
+	}
+
+	id := strconv.Itoa(extension)
+	plantLock.Lock()
+	defer plantLock.Unlock()
+	lock.Lock()
+	defer lock.Unlock()
+	if !coventryConfig.HasSection(id) {
+		return fmt.Errorf("line %s does not exist", id)
+	}
+
+	if len(coventryCustom.Section(id).Keys()) > 0 {
+		return fmt.Errorf("custom lines not changeable")
+	}
+
+	// cabled lines stay in the room of their jack
+	jack := plantJacks[GetConfig(coventryConfig.Section(id), "cabling", "")]
+	if jack != nil && jack.Room != room {
+		return fmt.Errorf("line %s is cabled to jack %s in %s", id, jack.Id, jack.Room)
+	}
+
+	section := coventryUpdate.Section(id)
+	if len(room) > 0 && room != "any" {
+		if !coventryConfig.Section("rooms").HasKey(room) {
+			return fmt.Errorf("room %s does not exist", room)
+		}
+		SetConfig(section, "room", room)
+	} else {
+		section.DeleteKey("room")
+	}
+	return saveUpdate()
+}
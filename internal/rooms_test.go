@@ -0,0 +1,60 @@
+// Copyright (C) 2023 Tycho Softworks.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package apollo
+
+import (
+	"testing"
+)
+
+func TestRooms(t *testing.T) {
+	emptyConfig(t)
+	coventryConfig.Section("10")
+
+	if err := UpdateRoom("lobby", "front"); err == nil {
+		t.Errorf("Expected error for missing zone")
+	}
+
+	// changes are visible once coventry config reloads
+	UpdateZone("front", "Front Office")
+	coventryConfig.Section("zones").Key("front").SetValue("Front Office")
+	if err := UpdateRoom("lobby", "front"); err != nil {
+		t.Fatal(err)
+	}
+
+	coventryConfig.Section("rooms").Key("lobby").SetValue("front")
+	if err := UpdateLineRoom(10, "lobby"); err != nil {
+		t.Fatal(err)
+	}
+
+	coventryConfig.Section("10").Key("room").SetValue("lobby")
+	if err := RemoveRoom("lobby"); err == nil {
+		t.Errorf("Expected error removing room with lines")
+	}
+
+	if err := RemoveZone("front"); err == nil {
+		t.Errorf("Expected error removing zone with rooms")
+	}
+
+	rooms := GetRoomList()
+	if len(rooms) != 1 || rooms[0].Zone != "front" || len(rooms[0].Lines) != 1 {
+		t.Errorf("Unexpected rooms %+v", rooms)
+	}
+
+	zones := GetZoneList()
+	if len(zones) != 1 || zones[0].Description != "Front Office" || zones[0].Rooms[0] != "lobby" {
+		t.Errorf("Unexpected zones %+v", zones)
+	}
+}
@@ -0,0 +1,264 @@
+// Copyright (C) 2023 Tycho Softworks.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package apollo
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	TicketTitle = 80
+	TicketNote  = 2000
+)
+
+type Note struct {
+	Created time.Time `json:"created"`
+	Author  string    `json:"author"`
+	Text    string    `json:"text"`
+}
+
+type Ticket struct {
+	Id       int       `json:"id"`
+	Title    string    `json:"title"`
+	State    string    `json:"state"`
+	Assigned string    `json:"assigned,omitempty"`
+	Line     int       `json:"line,omitempty"`
+	Created  time.Time `json:"created"`
+	Updated  time.Time `json:"updated"`
+	Notes    []*Note   `json:"notes"`
+}
+
+var (
+	ticketLock  sync.Mutex
+	ticketPath  string
+	ticketsList = make(map[int]*Ticket)
+	ticketNext  = 1
+)
+
+func isTicketState(state string) bool {
+	return state == "open" || state == "assigned" || state == "resolved"
+}
+
+// copy so callers never share notes with the store
+func (ticket *Ticket) clone() *Ticket {
+	copy := *ticket
+	copy.Notes = append([]*Note(nil), ticket.Notes...)
+	return &copy
+}
+
+// load ticket store
+func Tickets(path string) error {
+	ticketLock.Lock()
+	defer ticketLock.Unlock()
+	ticketPath = path
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	var tickets []*Ticket
+	err = json.Unmarshal(data, &tickets)
+	if err != nil {
+		return err
+	}
+
+	ticketsList = make(map[int]*Ticket)
+	for _, ticket := range tickets {
+		ticketsList[ticket.Id] = ticket
+		if ticket.Id >= ticketNext {
+			ticketNext = ticket.Id + 1
+		}
+	}
+	return nil
+}
+
+func saveTickets() error {
+	if len(ticketPath) == 0 {
+		return nil
+	}
+
+	tickets := make([]*Ticket, 0, len(ticketsList))
+	for _, ticket := range ticketsList {
+		tickets = append(tickets, ticket)
+	}
+	sort.Slice(tickets, func(i, j int) bool {
+		return tickets[i].Id < tickets[j].Id
+	})
+
+	data, err := json.MarshalIndent(tickets, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := ticketPath + ".tmp"
+	err = os.WriteFile(tmp, data, 0640)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, ticketPath)
+}
+
+// tickets newest first, optionally by state and line
+func GetTickets(state string, line int) []*Ticket {
+	ticketLock.Lock()
+	defer ticketLock.Unlock()
+	tickets := make([]*Ticket, 0)
+	for _, ticket := range ticketsList {
+		if state != "" && ticket.State != state {
+			continue
+		}
+		if line != 0 && ticket.Line != line {
+			continue
+		}
+		tickets = append(tickets, ticket.clone())
+	}
+	sort.Slice(tickets, func(i, j int) bool {
+		return tickets[i].Id > tickets[j].Id
+	})
+	return tickets
+}
+
+// unresolved tickets of a line
+func OpenTickets(line int) []*Ticket {
+	var tickets []*Ticket
+	for _, ticket := range GetTickets("", line) {
+		if ticket.State != "resolved" {
+			tickets = append(tickets, ticket)
+		}
+	}
+	return tickets
+}
+
+func GetTicket(id int) *Ticket {
+	ticketLock.Lock()
+	defer ticketLock.Unlock()
+	ticket := ticketsList[id]
+	if ticket == nil {
+		return nil
+	}
+	return ticket.clone()
+}
+
+// web form values may be reused buffers, so strings kept are cloned
+func NewTicket(title, text, author string, line int) (*Ticket, error) {
+	title = strings.Clone(strings.TrimSpace(title))
+	text = strings.Clone(strings.TrimSpace(text))
+	author = strings.Clone(author)
+	if title == "" {
+		return nil, fmt.Errorf("ticket title missing")
+	}
+
+	if len(title) > TicketTitle {
+		return nil, fmt.Errorf("ticket title longer than %d", TicketTitle)
+	}
+
+	if len(text) > TicketNote {
+		return nil, fmt.Errorf("ticket note longer than %d", TicketNote)
+	}
+
+	if line != 0 && !ExistsLine(line) {
+		return nil, fmt.Errorf("line %d does not exist", line)
+	}
+
+	now := time.Now()
+	ticketLock.Lock()
+	defer ticketLock.Unlock()
+	ticket := &Ticket{
+		Id:      ticketNext,
+		Title:   title,
+		State:   "open",
+		Line:    line,
+		Created: now,
+		Updated: now,
+		Notes:   make([]*Note, 0),
+	}
+
+	if text != "" {
+		ticket.Notes = append(ticket.Notes, &Note{Created: now, Author: author, Text: text})
+	}
+
+	ticketsList[ticket.Id] = ticket
+	ticketNext++
+	return ticket.clone(), saveTickets()
+}
+
+func UpdateTicket(id int, state, assigned, author string) error {
+	assigned = strings.Clone(strings.TrimSpace(assigned))
+	author = strings.Clone(author)
+	if !isTicketState(state) {
+		return fmt.Errorf("invalid ticket state %s", state)
+	}
+
+	if state == "assigned" && assigned == "" {
+		return fmt.Errorf("assigned ticket needs an assignee")
+	}
+
+	ticketLock.Lock()
+	defer ticketLock.Unlock()
+	ticket := ticketsList[id]
+	if ticket == nil {
+		return fmt.Errorf("ticket %d does not exist", id)
+	}
+
+	if ticket.State == state && ticket.Assigned == assigned {
+		return nil
+	}
+
+	now := time.Now()
+	text := "state " + state
+	if assigned != "" {
+		text += " to " + assigned
+	}
+	ticket.State = strings.Clone(state)
+	ticket.Assigned = assigned
+	ticket.Updated = now
+	ticket.Notes = append(ticket.Notes, &Note{Created: now, Author: author, Text: text})
+	return saveTickets()
+}
+
+func AddNote(id int, author, text string) error {
+	text = strings.Clone(strings.TrimSpace(text))
+	author = strings.Clone(author)
+	if text == "" {
+		return fmt.Errorf("note missing")
+	}
+
+	if len(text) > TicketNote {
+		return fmt.Errorf("ticket note longer than %d", TicketNote)
+	}
+
+	ticketLock.Lock()
+	defer ticketLock.Unlock()
+	ticket := ticketsList[id]
+	if ticket == nil {
+		return fmt.Errorf("ticket %d does not exist", id)
+	}
+
+	now := time.Now()
+	ticket.Updated = now
+	ticket.Notes = append(ticket.Notes, &Note{Created: now, Author: author, Text: text})
+	return saveTickets()
+}
@@ -0,0 +1,62 @@
+// Copyright (C) 2023 Tycho Softworks.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package apollo
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestTickets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tickets.json")
+	if err := Tickets(path); err != nil {
+		t.Fatal(err)
+	}
+
+	ticket, err := NewTicket("No dial tone", "reported by front desk", "admin", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = NewTicket("", "", "admin", 0); err == nil {
+		t.Errorf("Expected error for missing title")
+	}
+
+	if err = UpdateTicket(ticket.Id, "assigned", "", "admin"); err == nil {
+		t.Errorf("Expected error for assigned without assignee")
+	}
+
+	if err = UpdateTicket(ticket.Id, "assigned", "tech", "admin"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = AddNote(ticket.Id, "tech", "replaced cable"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = Tickets(path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := GetTicket(ticket.Id)
+	if loaded == nil || loaded.State != "assigned" || loaded.Assigned != "tech" || len(loaded.Notes) != 3 {
+		t.Errorf("Unexpected ticket after reload %+v", loaded)
+	}
+
+	if len(GetTickets("open", 0)) != 0 || len(GetTickets("assigned", 0)) != 1 {
+		t.Errorf("Unexpected ticket state filter")
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright (C) 2023 Tycho Softworks.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package apollo
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Trunk struct {
+	Host     string `ini:"host" json:"host"`
+	Username string `ini:"username" json:"username"`
+	Secret   string `ini:"secret" json:"-"`
+	Caller   string `ini:"caller" json:"caller"`
+	Prefix   string `ini:"prefix" json:"prefix"`
+	Editable bool   `ini:"-" json:"-"`
+}
+
+func GetTrunk() *Trunk {
+	trunk := &Trunk{Editable: true}
+	lock.RLock()
+	defer lock.RUnlock()
+	coventryConfig.Section("trunk").MapTo(trunk)
+	if len(coventryCustom.Section("trunk").Keys()) > 0 {
+		trunk.Editable = false
+	}
+	return trunk
+}
+
+func isDigits(value string, extra string) bool {
+	for _, ch := range value {
+		if (ch < '0' || ch > '9') && !strings.ContainsRune(extra, ch) {
+			return false
+		}
+	}
+	return true
+}
+
+func UpdateTrunk(trunk *Trunk) error {
+	if strings.ContainsAny(trunk.Host, " \t;,") {
+		return fmt.Errorf("invalid trunk host")
+	}
+
+	if strings.ContainsAny(trunk.Username, " \t;,:@") {
+		return fmt.Errorf("invalid trunk username")
+	}
+
+	if !isDigits(trunk.Caller, "+") {
+		return fmt.Errorf("invalid trunk caller id")
+	}
+
+	if len(trunk.Prefix) > 4 || !isDigits(trunk.Prefix, "") {
+		return fmt.Errorf("invalid outbound prefix")
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	if len(coventryCustom.Section("trunk").Keys()) > 0 {
+		return fmt.Errorf("custom trunk not changeable")
+	}
+
+	coventryUpdate.DeleteSection("trunk")
+	if len(trunk.Host) > 0 {
+		err := updateKeys(coventryUpdate, "trunk", trunk)
+		if err != nil {
+			return err
+		}
+	}
+	return saveUpdate()
+}
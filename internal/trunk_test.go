@@ -0,0 +1,65 @@
+// Copyright (C) 2023 Tycho Softworks.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package apollo
+
+import (
+	"testing"
+)
+
+func TestTrunk(t *testing.T) {
+	emptyConfig(t)
+
+	invalid := []Trunk{
+		{Host: "sip.example.com;lr"},
+		{Host: "sip example.com"},
+		{Host: "sip.example.com", Username: "user@example.com"},
+		{Host: "sip.example.com", Username: "user:pass"},
+		{Host: "sip.example.com", Caller: "555-1212"},
+		{Host: "sip.example.com", Caller: "5551212x"},
+		{Host: "sip.example.com", Prefix: "12345"},
+		{Host: "sip.example.com", Prefix: "+9"},
+	}
+
+	for _, trunk := range invalid {
+		if err := UpdateTrunk(&trunk); err == nil {
+			t.Errorf("Expected error for trunk %+v", trunk)
+		}
+	}
+
+	trunk := &Trunk{Host: "sip.example.com", Username: "office", Secret: "secret", Caller: "+15551212", Prefix: "9"}
+	if err := UpdateTrunk(trunk); err != nil {
+		t.Fatal(err)
+	}
+
+	section := coventryUpdate.Section("trunk")
+	if section.Key("host").String() != "sip.example.com" || section.Key("caller").String() != "+15551212" || section.Key("prefix").String() != "9" {
+		t.Errorf("Trunk not saved")
+	}
+
+	// an empty host removes the trunk
+	if err := UpdateTrunk(&Trunk{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if coventryUpdate.HasSection("trunk") {
+		t.Errorf("Trunk not removed")
+	}
+
+	coventryCustom.Section("trunk").Key("host").SetValue("sip.example.com")
+	if err := UpdateTrunk(trunk); err == nil {
+		t.Errorf("Expected error for custom trunk")
+	}
+}
@@ -0,0 +1,39 @@
+// Copyright (C) 2024 David Sugar, Tycho Softworks
+// This code is licensed under MIT license
+
+function updateCell(row, name, value) {
+    var cell = row.querySelector("." + name);
+    if (cell === null) {
+        return;
+    }
+
+    // keep a link to the agent, only changing the text shown
+    var link = cell.querySelector("a.link");
+    if (link !== null) {
+        cell = link;
+    }
+
+    if (cell.textContent.trim() !== String(value)) {
+        cell.textContent = value;
+    }
+}
+
+function registryEvents() {
+    if (typeof(EventSource) === "undefined") {
+        setInterval(function() { location.reload(); }, 10000);
+        return;
+    }
+
+    var source = new EventSource("/events");
+    source.addEventListener("registry", function(event) {
+        var entry = JSON.parse(event.data);
+        var row = document.getElementById("line-" + entry.id);
+        if (row === null) {
+            return;
+        }
+        updateCell(row, "status", entry.status);
+        updateCell(row, "agent", entry.agent);
+    });
+}
+
+window.addEventListener("load", registryEvents);